@@ -1,15 +1,79 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"json-parser/pkg/encoder"
 	"json-parser/pkg/parser"
 	"json-parser/pkg/tokenizer"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
 func main() {
-	args := os.Args[1:]
+	opts := parser.DefaultOptions()
+	flag.BoolVar(&opts.RFC4627, "rfc4627", false, "only accept an object or array as the document, as RFC 4627 requires")
+	ndjson := flag.Bool("ndjson", false, "read one document per line and report how many are valid")
+	jsonSeq := flag.Bool("json-seq", false, "read an RFC 7464 JSON text sequence and report how many records are valid")
+	concatenated := flag.Bool("concatenated", false, "read documents written back to back and report how many are valid")
+	flag.IntVar(&opts.MaxDepth, "max-depth", opts.MaxDepth, "maximum nesting of arrays and objects (0 for no limit)")
+	flag.Int64Var(&opts.MaxBytes, "max-bytes", opts.MaxBytes, "maximum size of the document in bytes (0 for no limit)")
+	flag.IntVar(&opts.MaxStringLength, "max-string-length", opts.MaxStringLength, "maximum length of a string in bytes (0 for no limit)")
+	flag.IntVar(&opts.MaxNumberLength, "max-number-length", opts.MaxNumberLength, "maximum length of a number literal (0 for no limit)")
+	flag.IntVar(&opts.MaxMembers, "max-members", opts.MaxMembers, "maximum members of an object or elements of an array (0 for no limit)")
+	flag.IntVar(&opts.MaxTokens, "max-tokens", opts.MaxTokens, "maximum number of tokens in the document (0 for no limit)")
+	flag.BoolVar(&opts.CheckPrecision, "check-precision", false, "warn about numbers that change when read as a float64, and exit with 1 if there are any")
+	dialect := flag.String("dialect", tokenizer.DialectJSON.String(), "input syntax: json, jsonc (comments and trailing commas), json5 or hjson")
+	lenientNumbers := flag.Bool("lenient-numbers", false, "accept NaN, Infinity and -Infinity as numbers")
+	toJSON := flag.Bool("to-json", false, "write the document to stdout as indented JSON")
+	nonFinite := flag.String("non-finite", encoder.NonFiniteError.String(), "how --to-json writes NaN, Infinity and -Infinity: error, null or literal")
+	profile := flag.String("profile", opts.Profile.String(), "rules to check on top of RFC 8259: none or i-json")
+	duplicateKeys := flag.String("duplicate-keys", opts.DuplicateKeys.String(), "what to do with repeated object keys: reject, warn, first, last or all")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	policy, ok := parser.ParseDuplicateKeyPolicy(*duplicateKeys)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown duplicate key policy %q\n", *duplicateKeys)
+		os.Exit(2)
+	}
+	opts.DuplicateKeys = policy
+	if opts.Profile, ok = parser.ParseProfile(*profile); !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown profile %q\n", *profile)
+		os.Exit(2)
+	}
+	var tokOpts tokenizer.Options
+	if tokOpts.Dialect, ok = tokenizer.ParseDialect(*dialect); !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown dialect %q\n", *dialect)
+		os.Exit(2)
+	}
+	tokOpts.LenientNumbers = *lenientNumbers
+	if opts.Profile == parser.ProfileIJSON && tokOpts.Dialect != tokenizer.DialectJSON {
+		fmt.Fprintf(os.Stderr, "Error: --profile=i-json only reads JSON, not --dialect=%s\n", tokOpts.Dialect)
+		os.Exit(2)
+	}
+	if opts.Profile == parser.ProfileIJSON && tokOpts.LenientNumbers {
+		fmt.Fprintln(os.Stderr, "Error: --profile=i-json does not allow --lenient-numbers")
+		os.Exit(2)
+	}
+	encOpts := encoder.Options{Indent: "  "}
+	if encOpts.NonFinite, ok = encoder.ParseNonFinitePolicy(*nonFinite); !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown non-finite policy %q\n", *nonFinite)
+		os.Exit(2)
+	}
+	imprecise := 0
+	opts.OnWarning = func(warning *parser.ParseError) {
+		if warning.Kind == parser.KindPrecisionLoss {
+			imprecise++
+		}
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+
+	args := flag.Args()
 	var t *tokenizer.Tokenizer
 	switch len(args) {
 	case 1:
@@ -19,19 +83,87 @@ func main() {
 			log.Fatalf("Unable to read file: %v", err)
 		}
 		defer file.Close()
-		t = tokenizer.NewTokenizerFromReader(file)
+		t = tokenizer.NewTokenizerFromReaderWithOptions(file, tokOpts)
 	default:
 		if isInputFromPipe() {
-			t = tokenizer.NewTokenizerFromReader(os.Stdin)
+			t = tokenizer.NewTokenizerFromReaderWithOptions(os.Stdin, tokOpts)
 		} else {
-			printUsageAndExit()
+			printUsage()
+			os.Exit(1)
 		}
 	}
-	if parser.Parse(t) {
-		os.Exit(0)
-	} else {
+	if *toJSON && (*ndjson || *jsonSeq || *concatenated) {
+		fmt.Fprintln(os.Stderr, "Error: --to-json converts a single document")
+		os.Exit(2)
+	}
+	var err error
+	valid := true
+	switch {
+	case *ndjson:
+		valid = validateStream(t, parser.StreamLines, opts)
+	case *jsonSeq:
+		valid = validateStream(t, parser.StreamRecordSeparated, opts)
+	case *concatenated:
+		valid = validateStream(t, parser.StreamConcatenated, opts)
+	case *toJSON && (opts.DuplicateKeys == parser.DuplicateKeepFirst || opts.DuplicateKeys == parser.DuplicateKeepLast):
+		// Only the document tree drops repeated keys, the handler sees them all
+		var v *parser.Value
+		if v, err = parser.ParseValueWithOptions(t, opts); err == nil {
+			err = encoder.NewEncoderWithOptions(os.Stdout, encOpts).Encode(v)
+		}
+	case *toJSON:
+		err = parser.ParseWithHandler(t, encoder.NewEncoderWithOptions(os.Stdout, encOpts), opts)
+	default:
+		err = parser.ParseWithHandler(t, parser.NopHandler{}, opts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
+	if imprecise > 0 {
+		fmt.Fprintf(os.Stderr, "%d numbers lose precision as a float64\n", imprecise)
+		os.Exit(1)
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// validateStream checks every document of the input and reports the lines
+// where invalid ones start. It returns whether every document is valid.
+func validateStream(t *tokenizer.Tokenizer, mode parser.StreamMode, opts parser.Options) bool {
+	r := parser.NewDocumentReaderWithOptions(t, mode, opts)
+	valid := 0
+	var invalid []string
+	for {
+		err := r.Next(parser.NopHandler{})
+		if err == io.EOF {
+			break
+		}
+		var parseErr *parser.ParseError
+		if errors.As(err, &parseErr) && (parseErr.Kind == parser.KindRead || parseErr.Kind == parser.KindDocumentTooLarge || parseErr.Kind == parser.KindCanceled) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		if err != nil && mode == parser.StreamConcatenated {
+			// Without framing nothing after the error can be trusted
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			invalid = append(invalid, strconv.Itoa(r.Start().Line))
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			invalid = append(invalid, strconv.Itoa(r.Start().Line))
+			continue
+		}
+		valid++
+	}
+
+	fmt.Printf("valid: %d, invalid: %d\n", valid, len(invalid))
+	if len(invalid) > 0 {
+		fmt.Printf("invalid lines: %s\n", strings.Join(invalid, ", "))
+	}
+	return len(invalid) == 0
 }
 
 func isInputFromPipe() bool {
@@ -39,7 +171,7 @@ func isInputFromPipe() bool {
 	return (stat.Mode() & os.ModeCharDevice) == 0
 }
 
-func printUsageAndExit() {
-	fmt.Println("Usage: json-parser <filename> or cat <filename> | json-parser")
-	os.Exit(1)
+func printUsage() {
+	fmt.Println("Usage: json-parser [flags] <filename> or cat <filename> | json-parser [flags]")
+	flag.PrintDefaults()
 }
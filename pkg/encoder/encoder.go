@@ -0,0 +1,369 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"json-parser/pkg/parser"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format selects the syntax an Encoder writes
+type Format int
+
+const (
+	FormatJSON  Format = iota // RFC 8259
+	FormatJSON5               // JSON5, with identifier keys and single-quoted strings
+)
+
+var formatNames = map[Format]string{
+	FormatJSON:  "json",
+	FormatJSON5: "json5",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, bool) {
+	for format, formatName := range formatNames {
+		if formatName == name {
+			return format, true
+		}
+	}
+	return 0, false
+}
+
+// NonFinitePolicy decides how Infinity, -Infinity and NaN are written as
+// JSON, which has no way to write them. JSON5 always writes them as they are.
+type NonFinitePolicy int
+
+const (
+	NonFiniteError   NonFinitePolicy = iota // fail with ErrNonFinite
+	NonFiniteNull                           // write null, as JavaScript's JSON.stringify does
+	NonFiniteLiteral                        // write them as they are, as Python's json module does
+)
+
+var nonFiniteNames = map[NonFinitePolicy]string{
+	NonFiniteError:   "error",
+	NonFiniteNull:    "null",
+	NonFiniteLiteral: "literal",
+}
+
+func (p NonFinitePolicy) String() string {
+	if name, ok := nonFiniteNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseNonFinitePolicy returns the policy with the given name
+func ParseNonFinitePolicy(name string) (NonFinitePolicy, bool) {
+	for policy, policyName := range nonFiniteNames {
+		if policyName == name {
+			return policy, true
+		}
+	}
+	return 0, false
+}
+
+// Options configures an Encoder. The zero value writes compact JSON.
+type Options struct {
+	Format Format
+	// Indent, when set, puts every element and member on a line of its own,
+	// indented by Indent once per level of nesting
+	Indent    string
+	NonFinite NonFinitePolicy
+}
+
+// ErrNonFinite is returned for Infinity, -Infinity or NaN written as JSON
+// with NonFiniteError
+var ErrNonFinite = errors.New("JSON cannot represent Infinity or NaN")
+
+// level is an array or object the encoder is inside of
+type level struct {
+	object bool
+	count  int // elements or members written so far
+}
+
+// Encoder writes documents as JSON or JSON5. Each document is followed by a
+// line feed, and is written to the underlying writer in one go once it is
+// complete, so a document that fails halfway writes nothing.
+//
+// Encoder is a parser.Handler, so a document can be converted while it is
+// parsed:
+//
+//	enc := encoder.NewEncoderWithOptions(os.Stdout, encoder.Options{Format: encoder.FormatJSON5})
+//	err := parser.ParseWithHandler(t, enc, parser.DefaultOptions())
+//
+// It is also a parser.ResetHandler: before each document the parser drops
+// whatever a failed one left behind, so the same Encoder can be used again.
+type Encoder struct {
+	w     io.Writer
+	opts  Options
+	buf   []byte // the document being written
+	stack []level
+	key   bool // a key was written, its value comes next
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderWithOptions(w, Options{})
+}
+
+func NewEncoderWithOptions(w io.Writer, opts Options) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// Encode writes v as a document
+func (e *Encoder) Encode(v *parser.Value) error {
+	e.Reset()
+	if err := e.value(v); err != nil {
+		e.Reset()
+		return err
+	}
+	return nil
+}
+
+func (e *Encoder) value(v *parser.Value) error {
+	switch v.Kind() {
+	case parser.BoolValue:
+		b, _ := v.Bool()
+		return e.Bool(b)
+	case parser.NumberValue:
+		n, _ := v.Number()
+		return e.Number(n.String())
+	case parser.StringValue:
+		s, _ := v.Str()
+		return e.String(s)
+	case parser.ArrayValue:
+		e.StartArray()
+		for _, element := range v.Elements() {
+			if err := e.value(element); err != nil {
+				return err
+			}
+		}
+		return e.EndArray()
+	case parser.ObjectValue:
+		e.StartObject()
+		for key, member := range v.Members() {
+			e.Key(key)
+			if err := e.value(member); err != nil {
+				return err
+			}
+		}
+		return e.EndObject()
+	default:
+		return e.Null()
+	}
+}
+
+func (e *Encoder) StartObject() error {
+	e.open(true, '{')
+	return nil
+}
+
+func (e *Encoder) Key(key string) error {
+	e.separate()
+	if e.opts.Format == FormatJSON5 && isIdentifier(key) {
+		e.buf = append(e.buf, key...)
+	} else {
+		e.buf = e.appendString(e.buf, key)
+	}
+	e.buf = append(e.buf, ':')
+	if e.opts.Indent != "" {
+		e.buf = append(e.buf, ' ')
+	}
+	e.key = true
+	return nil
+}
+
+func (e *Encoder) EndObject() error {
+	return e.close('}')
+}
+
+func (e *Encoder) StartArray() error {
+	e.open(false, '[')
+	return nil
+}
+
+func (e *Encoder) EndArray() error {
+	return e.close(']')
+}
+
+func (e *Encoder) String(s string) error {
+	e.separate()
+	e.buf = e.appendString(e.buf, s)
+	return e.end()
+}
+
+// Number writes literal as it is, so it has to be a valid number of the
+// format, or Infinity, -Infinity or NaN, which JSON writes as the NonFinite
+// policy says
+func (e *Encoder) Number(literal string) error {
+	if e.opts.Format == FormatJSON && !parser.Number(literal).IsFinite() {
+		switch e.opts.NonFinite {
+		case NonFiniteNull:
+			return e.Null()
+		case NonFiniteError:
+			e.Reset()
+			return fmt.Errorf("writing %s: %w", literal, ErrNonFinite)
+		}
+	}
+	e.separate()
+	e.buf = append(e.buf, literal...)
+	return e.end()
+}
+
+func (e *Encoder) Bool(b bool) error {
+	e.separate()
+	if b {
+		e.buf = append(e.buf, "true"...)
+	} else {
+		e.buf = append(e.buf, "false"...)
+	}
+	return e.end()
+}
+
+func (e *Encoder) Null() error {
+	e.separate()
+	e.buf = append(e.buf, "null"...)
+	return e.end()
+}
+
+// separate writes what goes before the next element, member or document
+func (e *Encoder) separate() {
+	if e.key {
+		e.key = false
+		return
+	}
+	if len(e.stack) == 0 {
+		return
+	}
+	top := &e.stack[len(e.stack)-1]
+	if top.count > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	top.count++
+	e.newline()
+}
+
+// newline starts a new line at the current depth when indenting
+func (e *Encoder) newline() {
+	if e.opts.Indent == "" {
+		return
+	}
+	e.buf = append(e.buf, '\n')
+	for range e.stack {
+		e.buf = append(e.buf, e.opts.Indent...)
+	}
+}
+
+func (e *Encoder) open(object bool, char byte) {
+	e.separate()
+	e.buf = append(e.buf, char)
+	e.stack = append(e.stack, level{object: object})
+}
+
+func (e *Encoder) close(char byte) error {
+	top := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	if top.count > 0 {
+		e.newline()
+	}
+	e.buf = append(e.buf, char)
+	return e.end()
+}
+
+// end writes the document once its last value is complete
+func (e *Encoder) end() error {
+	if len(e.stack) > 0 {
+		return nil
+	}
+	e.buf = append(e.buf, '\n')
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+	return err
+}
+
+// Reset drops the document being written, if any, so the next event starts
+// a new one
+func (e *Encoder) Reset() {
+	e.buf, e.stack, e.key = e.buf[:0], e.stack[:0], false
+}
+
+// appendString appends s as a quoted string. JSON5 strings are quoted with
+// whichever quote needs fewer escapes, single quotes on a tie.
+func (e *Encoder) appendString(buf []byte, s string) []byte {
+	quote := byte('"')
+	if e.opts.Format == FormatJSON5 && strings.Count(s, "'") <= strings.Count(s, `"`) {
+		quote = '\''
+	}
+
+	buf = append(buf, quote)
+	start := 0 // start of the bytes not yet copied
+	for i := 0; i < len(s); {
+		char := s[i]
+		if char >= 0x20 && char != quote && char != '\\' && char < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		escape := ""
+		switch {
+		case char == quote || char == '\\':
+			escape = `\` + string(char)
+		case char == '\b':
+			escape = `\b`
+		case char == '\f':
+			escape = `\f`
+		case char == '\n':
+			escape = `\n`
+		case char == '\r':
+			escape = `\r`
+		case char == '\t':
+			escape = `\t`
+		case char < 0x20 && e.opts.Format == FormatJSON5:
+			escape = fmt.Sprintf(`\x%02x`, char)
+		case char < 0x20:
+			escape = fmt.Sprintf(`\u%04x`, char)
+		case r == utf8.RuneError && n == 1:
+			escape = `\ufffd`
+		case r == '\u2028' || r == '\u2029':
+			// Line terminators in JavaScript, which JSON5 is read as
+			escape = fmt.Sprintf(`\u%04x`, r)
+		}
+		if escape != "" {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, escape...)
+			start = i + n
+		}
+		i += n
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, quote)
+}
+
+// isIdentifier reports whether key can be written as a JSON5 identifier.
+// Only ASCII identifiers are, so the output is easy to read for any JSON5
+// reader.
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		char := key[i]
+		switch {
+		case char == '$' || char == '_':
+		case char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z':
+		case char >= '0' && char <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package parser
+
+// builder is the Handler that assembles a document tree
+type builder struct {
+	duplicates DuplicateKeyPolicy
+	root       *Value
+	stack      []openValue // open arrays and objects
+	key        string      // key of the object member being read
+}
+
+// openValue is an open array or object
+type openValue struct {
+	value *Value
+	// index maps the keys of an object to their member, kept only when
+	// duplicate keys are dropped
+	index map[string]int
+}
+
+func (b *builder) StartObject() error {
+	b.push(NewObject())
+	return nil
+}
+
+func (b *builder) Key(key string) error {
+	b.key = key
+	return nil
+}
+
+func (b *builder) EndObject() error {
+	b.stack = b.stack[:len(b.stack)-1]
+	return nil
+}
+
+func (b *builder) StartArray() error {
+	b.push(NewArray())
+	return nil
+}
+
+func (b *builder) EndArray() error {
+	b.stack = b.stack[:len(b.stack)-1]
+	return nil
+}
+
+func (b *builder) String(s string) error {
+	b.value(NewString(s))
+	return nil
+}
+
+func (b *builder) Number(literal string) error {
+	b.value(NewNumber(literal))
+	return nil
+}
+
+func (b *builder) Bool(v bool) error {
+	b.value(NewBool(v))
+	return nil
+}
+
+func (b *builder) Null() error {
+	b.value(NewNull())
+	return nil
+}
+
+// value attaches v to the innermost open container, or makes it the root
+func (b *builder) value(v *Value) {
+	if len(b.stack) == 0 {
+		b.root = v
+		return
+	}
+
+	top := b.stack[len(b.stack)-1]
+	parent := top.value
+	if parent.kind == ObjectValue {
+		if top.index != nil {
+			if i, ok := top.index[b.key]; ok {
+				// A dropped value is still filled in when it is a container,
+				// it is just never attached
+				if b.duplicates == DuplicateKeepLast {
+					parent.members[i].Value = v
+				}
+				return
+			}
+			top.index[b.key] = len(parent.members)
+		}
+		parent.members = append(parent.members, Member{Key: b.key, Value: v})
+	} else {
+		parent.elements = append(parent.elements, v)
+	}
+}
+
+func (b *builder) push(v *Value) {
+	b.value(v)
+	c := openValue{value: v}
+	if v.kind == ObjectValue && (b.duplicates == DuplicateKeepFirst || b.duplicates == DuplicateKeepLast) {
+		c.index = map[string]int{}
+	}
+	b.stack = append(b.stack, c)
+}
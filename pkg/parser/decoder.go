@@ -0,0 +1,499 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+)
+
+// EventKind identifies what an Event reports
+type EventKind int
+
+const (
+	EventStartObject EventKind = iota
+	EventEndObject
+	EventStartArray
+	EventEndArray
+	EventKey
+	EventString
+	EventNumber
+	EventBool
+	EventNull
+)
+
+var eventKindNames = map[EventKind]string{
+	EventStartObject: "start of object",
+	EventEndObject:   "end of object",
+	EventStartArray:  "start of array",
+	EventEndArray:    "end of array",
+	EventKey:         "key",
+	EventString:      "string",
+	EventNumber:      "number",
+	EventBool:        "bool",
+	EventNull:        "null",
+}
+
+func (k EventKind) String() string {
+	if name, ok := eventKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Event is a single step through a document, the pull equivalent of a
+// Handler call
+type Event struct {
+	Kind  EventKind
+	Value string             // key, string contents or number literal
+	Bool  bool               // value of an EventBool
+	Pos   tokenizer.Position // start of the token
+}
+
+// emit makes the Handler call matching the event
+func (e Event) emit(h Handler) error {
+	switch e.Kind {
+	case EventStartObject:
+		return h.StartObject()
+	case EventEndObject:
+		return h.EndObject()
+	case EventStartArray:
+		return h.StartArray()
+	case EventEndArray:
+		return h.EndArray()
+	case EventKey:
+		return h.Key(e.Value)
+	case EventString:
+		return h.String(e.Value)
+	case EventNumber:
+		return h.Number(e.Value)
+	case EventBool:
+		return h.Bool(e.Bool)
+	default:
+		return h.Null()
+	}
+}
+
+// ErrNoValue is returned by Decode and Skip when the next event is not the
+// start of a value, e.g. an object key or the end of a container
+var ErrNoValue = errors.New("the next token does not start a value")
+
+// expectation is what the grammar allows at the decoder's position
+type expectation int
+
+const (
+	expectValue      expectation = iota // document start, after ':' or after ',' in an array
+	expectValueOrEnd                    // after '[', or ',' with trailing commas
+	expectKeyOrEnd                      // after '{', or ',' with trailing commas
+	expectKey                           // after ',' in an object
+	expectColon                         // after a key
+	expectCommaOrEnd                    // after a value inside an array or object
+	expectEOF                           // after the document
+	expectNothing                       // io.EOF was returned
+)
+
+// container is an array or object the decoder is inside of
+type container struct {
+	object  bool
+	members int
+	seen    map[string]tokenizer.Position // keys, when duplicates are reported
+}
+
+// Decoder walks a document one event at a time, checking the grammar as it
+// goes. Unlike Parse it only reads as far as the caller asks it to, so the
+// caller can stop early or Decode just the values it is interested in:
+//
+//	d := parser.NewDecoder(t)
+//	d.Token() // EventStartArray
+//	for d.More() {
+//		value, err := d.Decode()
+//		...
+//	}
+//	d.Token() // EventEndArray
+type Decoder struct {
+	p      parser
+	expect expectation
+	peeked bool // p.token holds the next unread token
+	marked bool // the tokenizer keeps the input after the document
+	stack  []container
+	err    error // first error, returned from then on
+}
+
+func NewDecoder(t *tokenizer.Tokenizer) *Decoder {
+	return NewDecoderWithOptions(t, DefaultOptions())
+}
+
+func NewDecoderWithOptions(t *tokenizer.Tokenizer, opts Options) *Decoder {
+	opts = opts.configure(t)
+	return &Decoder{p: parser{t: t, h: NopHandler{}, opts: opts}}
+}
+
+// Token returns the next event of the document. Commas and colons are
+// checked but not reported. After the end of the document Token returns
+// io.EOF; once an error was returned, every later call returns it again.
+func (d *Decoder) Token() (Event, error) {
+	if d.err != nil {
+		return Event{}, d.err
+	}
+	event, err := d.next()
+	if err != nil {
+		if !isNeedMoreData(err) {
+			d.err = err
+		}
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// More reports whether the current array or object has another element or
+// member. At the top level it reports whether the document still has its
+// value. A malformed remainder also counts as more, Token then reports it.
+// With a feed tokenizer More is false while it cannot tell yet.
+func (d *Decoder) More() bool {
+	if d.err != nil || d.expect == expectNothing {
+		return false
+	}
+	if err := d.peekPastComma(); err != nil {
+		if isNeedMoreData(err) {
+			return false
+		}
+		d.err = err
+		return true
+	}
+	switch d.expect {
+	case expectValue, expectKey, expectColon:
+		return true
+	case expectEOF:
+		return d.p.token.Type != tokenizer.TokenEOF
+	default:
+		return !d.atEnd()
+	}
+}
+
+// Buffered returns the input read ahead from the underlying reader but not
+// used by the decoder, so a stream can carry something else after a
+// document: once Token has returned the document's last event, the data
+// after it is io.MultiReader(d.Buffered(), r), even when More has already
+// looked past the document.
+func (d *Decoder) Buffered() io.Reader {
+	if d.peeked && !d.marked {
+		return io.MultiReader(strings.NewReader(d.p.token.Raw), d.p.t.Buffered())
+	}
+	return d.p.t.Buffered()
+}
+
+// Skip moves past the next value, including everything nested in it. Inside
+// an object the key has to be read with Token first. With a feed tokenizer
+// the whole value has to be fed first, Skip cannot continue after
+// tokenizer.ErrNeedMoreData.
+func (d *Decoder) Skip() error {
+	return d.walk(NopHandler{})
+}
+
+// Decode reads the next value, including everything nested in it, into a
+// document tree. Inside an object the key has to be read with Token first.
+// Like Skip it cannot continue after tokenizer.ErrNeedMoreData.
+func (d *Decoder) Decode() (*Value, error) {
+	b := &builder{duplicates: d.p.opts.DuplicateKeys}
+	if err := d.walk(b); err != nil {
+		return nil, err
+	}
+	return b.root, nil
+}
+
+// walk reports the events of the next value to h
+func (d *Decoder) walk(h Handler) error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.expect == expectNothing {
+		return io.EOF
+	}
+	if err := d.peekPastComma(); err != nil {
+		if !isNeedMoreData(err) {
+			d.err = err
+		}
+		return err
+	}
+	if !d.atValue() {
+		if d.expect == expectEOF && d.p.token.Type == tokenizer.TokenEOF {
+			return io.EOF
+		}
+		return ErrNoValue
+	}
+
+	depth := 0
+	for {
+		event, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if err := event.emit(h); err != nil {
+			return err
+		}
+		switch event.Kind {
+		case EventStartObject, EventStartArray:
+			depth++
+		case EventEndObject, EventEndArray:
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// atValue reports whether the next event starts a value, judging from the
+// already peeked token
+func (d *Decoder) atValue() bool {
+	switch d.expect {
+	case expectValue, expectColon:
+		return true
+	case expectValueOrEnd:
+		return !d.atEnd()
+	case expectCommaOrEnd:
+		// Anything but the end of an array is either the comma before the
+		// next element or an error Token will report
+		return !d.stack[len(d.stack)-1].object && !d.atEnd()
+	}
+	return false
+}
+
+// atEnd reports whether the peeked token closes the current container
+func (d *Decoder) atEnd() bool {
+	if len(d.stack) == 0 {
+		return false
+	}
+	if d.stack[len(d.stack)-1].object {
+		return d.p.token.Type == tokenizer.TokenRightBrace
+	}
+	return d.p.token.Type == tokenizer.TokenRightSquare
+}
+
+// peek makes sure p.token holds the next unread token
+func (d *Decoder) peek() *ParseError {
+	if d.peeked {
+		return nil
+	}
+	if d.expect == expectEOF && !d.marked {
+		// Whatever follows the document stays available to Buffered
+		d.p.t.Mark()
+		d.marked = true
+	}
+	if err := d.p.advance(); err != nil {
+		return err
+	}
+	d.peeked = true
+	return nil
+}
+
+// peekPastComma is peek that, with trailing commas, also moves past a comma
+// after a value, so whether another value follows can be told from the token
+// after it
+func (d *Decoder) peekPastComma() *ParseError {
+	if err := d.peek(); err != nil {
+		return err
+	}
+	if !d.p.opts.TrailingCommas || d.expect != expectCommaOrEnd || d.p.token.Type != tokenizer.TokenComma {
+		return nil
+	}
+	d.take()
+	d.expectAfterComma()
+	return d.peek()
+}
+
+// take moves past the peeked token
+func (d *Decoder) take() tokenizer.Token {
+	d.peeked = false
+	return d.p.token
+}
+
+func (d *Decoder) next() (Event, error) {
+	if d.expect == expectNothing {
+		return Event{}, io.EOF
+	}
+
+	for {
+		if err := d.peek(); err != nil {
+			return Event{}, err
+		}
+		p := &d.p
+
+		switch d.expect {
+		case expectValue:
+			return d.value()
+		case expectValueOrEnd:
+			if d.atEnd() {
+				return d.end(), nil
+			}
+			if !slices.Contains(valueTokens, p.token.Type) {
+				return Event{}, p.unexpected(slices.Concat(valueTokens, []tokenizer.TokenType{tokenizer.TokenRightSquare})...)
+			}
+			return d.value()
+		case expectKeyOrEnd:
+			if d.atEnd() {
+				return d.end(), nil
+			}
+			if _, ok := p.key(); !ok {
+				return Event{}, p.unexpected(append(p.keyTokens(), tokenizer.TokenRightBrace)...)
+			}
+			return d.key()
+		case expectKey:
+			return d.key()
+		case expectColon:
+			if p.token.Type != tokenizer.TokenColon {
+				return Event{}, p.unexpected(tokenizer.TokenColon)
+			}
+			d.take()
+			d.expect = expectValue
+		case expectCommaOrEnd:
+			if d.atEnd() {
+				return d.end(), nil
+			}
+			top := d.stack[len(d.stack)-1]
+			if p.token.Type != tokenizer.TokenComma && p.opts.optionalCommas {
+				d.expectAfterComma()
+				continue
+			}
+			if p.token.Type != tokenizer.TokenComma {
+				if top.object {
+					return Event{}, p.unexpected(tokenizer.TokenComma, tokenizer.TokenRightBrace)
+				}
+				return Event{}, p.unexpected(tokenizer.TokenComma, tokenizer.TokenRightSquare)
+			}
+			d.take()
+			d.expectAfterComma()
+		case expectEOF:
+			if p.token.Type != tokenizer.TokenEOF {
+				return Event{}, p.unexpected(tokenizer.TokenEOF)
+			}
+			d.expect = expectNothing
+			return Event{}, io.EOF
+		}
+	}
+}
+
+// value reads the peeked token as the start of a value
+func (d *Decoder) value() (Event, error) {
+	p := &d.p
+	if len(d.stack) == 0 {
+		// RFC 8259 allows any value as the document, RFC 4627 only an object
+		// or an array
+		if p.opts.RFC4627 && p.token.Type != tokenizer.TokenLeftBrace && p.token.Type != tokenizer.TokenLeftSquare {
+			return Event{}, p.unexpected(tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare)
+		}
+	} else if top := &d.stack[len(d.stack)-1]; !top.object {
+		top.members++
+		if err := p.checkMembers(top.members); err != nil {
+			return Event{}, err
+		}
+	}
+
+	event := Event{Pos: p.token.Start}
+	switch p.token.Type {
+	case tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare:
+		if err := p.nest(); err != nil {
+			return Event{}, err
+		}
+		object := p.token.Type == tokenizer.TokenLeftBrace
+		c := container{object: object}
+		if object {
+			event.Kind = EventStartObject
+			d.expect = expectKeyOrEnd
+			if p.opts.DuplicateKeys == DuplicateReject || p.opts.DuplicateKeys == DuplicateWarn {
+				c.seen = map[string]tokenizer.Position{}
+			}
+		} else {
+			event.Kind = EventStartArray
+			d.expect = expectValueOrEnd
+		}
+		d.stack = append(d.stack, c)
+		d.take()
+		return event, nil
+	case tokenizer.TokenString:
+		event.Kind, event.Value = EventString, p.token.Value
+	case tokenizer.TokenNumber:
+		event.Kind, event.Value = EventNumber, p.token.Value
+	case tokenizer.TokenTrue:
+		event.Kind, event.Bool = EventBool, true
+	case tokenizer.TokenFalse:
+		event.Kind = EventBool
+	case tokenizer.TokenNull:
+		event.Kind = EventNull
+	default:
+		return Event{}, p.unexpected(valueTokens...)
+	}
+	d.take()
+	d.afterValue()
+	return event, nil
+}
+
+// key reads an object key, the colon after it is read by the next call
+func (d *Decoder) key() (Event, error) {
+	p := &d.p
+	key, ok := p.key()
+	if !ok {
+		return Event{}, p.unexpected(p.keyTokens()...)
+	}
+	top := &d.stack[len(d.stack)-1]
+	top.members++
+	if err := p.checkMembers(top.members); err != nil {
+		return Event{}, err
+	}
+	if top.seen != nil {
+		if previous, ok := top.seen[key]; ok {
+			if err := p.duplicateKey(previous); err != nil {
+				return Event{}, err
+			}
+		} else {
+			top.seen[key] = p.token.Start
+		}
+	}
+	token := d.take()
+	d.expect = expectColon
+	return Event{Kind: EventKey, Value: key, Pos: token.Start}, nil
+}
+
+// end reads the peeked token closing the current container
+func (d *Decoder) end() Event {
+	event := Event{Kind: EventEndArray, Pos: d.p.token.Start}
+	if d.stack[len(d.stack)-1].object {
+		event.Kind = EventEndObject
+	}
+	d.stack = d.stack[:len(d.stack)-1]
+	d.p.depth--
+	d.take()
+	d.afterValue()
+	return event
+}
+
+// expectAfterComma sets what may follow the comma after a value
+func (d *Decoder) expectAfterComma() {
+	object := d.stack[len(d.stack)-1].object
+	switch {
+	case d.p.opts.TrailingCommas && object:
+		d.expect = expectKeyOrEnd
+	case d.p.opts.TrailingCommas:
+		d.expect = expectValueOrEnd
+	case object:
+		d.expect = expectKey
+	default:
+		d.expect = expectValue
+	}
+}
+
+func (d *Decoder) afterValue() {
+	if len(d.stack) == 0 {
+		d.expect = expectEOF
+	} else {
+		d.expect = expectCommaOrEnd
+	}
+}
+
+// isNeedMoreData reports whether err only means a feed tokenizer has to be
+// fed more input, which leaves the decoder able to continue
+func isNeedMoreData(err error) bool {
+	var parseErr *ParseError
+	return errors.As(err, &parseErr) && parseErr.Kind == KindNeedMoreData
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"errors"
+	"json-parser/pkg/tokenizer"
+)
+
+// ErrorKind classifies why a document was rejected
+type ErrorKind int
+
+const (
+	KindUnexpectedToken ErrorKind = iota
+	KindUnexpectedCharacter
+	KindUnterminatedString
+	KindInvalidEscape
+	KindLoneSurrogate
+	KindControlCharacter
+	KindInvalidUTF8
+	KindInvalidNumber
+	KindInvalidLiteral
+	KindNestingLimit
+	KindUnexpectedEOF
+	KindRead
+	KindDocumentTooLarge
+	KindStringTooLong
+	KindNumberTooLong
+	KindTooManyMembers
+	KindTooManyTokens
+	KindDuplicateKey
+	KindHandler      // the Handler returned an error, see Err
+	KindTruncated    // a record of a JSON text sequence was cut short
+	KindNeedMoreData // fed input ends inside a token, see tokenizer.ErrNeedMoreData
+	KindCanceled     // the context passed to ParseContext is done
+	KindPrecisionLoss
+	KindProfile // the document breaks a rule of Options.Profile
+	KindUnterminatedComment
+)
+
+var kindNames = map[ErrorKind]string{
+	KindUnexpectedToken:     "unexpected token",
+	KindUnexpectedCharacter: "unexpected character",
+	KindUnterminatedString:  "unterminated string",
+	KindInvalidEscape:       "invalid escape",
+	KindLoneSurrogate:       "lone surrogate",
+	KindControlCharacter:    "control character",
+	KindInvalidUTF8:         "invalid UTF-8",
+	KindInvalidNumber:       "invalid number",
+	KindInvalidLiteral:      "invalid literal",
+	KindNestingLimit:        "nesting limit",
+	KindUnexpectedEOF:       "unexpected EOF",
+	KindRead:                "read error",
+	KindDocumentTooLarge:    "document too large",
+	KindStringTooLong:       "string too long",
+	KindNumberTooLong:       "number too long",
+	KindTooManyMembers:      "too many members",
+	KindTooManyTokens:       "too many tokens",
+	KindDuplicateKey:        "duplicate key",
+	KindHandler:             "handler error",
+	KindTruncated:           "truncated",
+	KindNeedMoreData:        "need more data",
+	KindCanceled:            "canceled",
+	KindPrecisionLoss:       "precision loss",
+	KindProfile:             "profile violation",
+	KindUnterminatedComment: "unterminated comment",
+}
+
+func (k ErrorKind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseError is the error returned by Parse for any rejected document
+type ParseError struct {
+	Kind     ErrorKind
+	Token    tokenizer.Token // offending token, zero for tokenizer errors
+	Message  string
+	Expected []tokenizer.TokenType // tokens the grammar allowed instead of Token
+	Pos      tokenizer.Position    // where the problem was found
+	Previous tokenizer.Position    // first occurrence of a duplicate key
+	Err      error                 // underlying tokenizer or handler error, if any
+}
+
+func (e *ParseError) Error() string {
+	return e.Message + " at " + e.Pos.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newParseError(kind ErrorKind, token tokenizer.Token, message string) *ParseError {
+	return &ParseError{Kind: kind, Token: token, Message: message, Pos: token.Start}
+}
+
+// fromTokenizerError converts an error returned by NextToken into a ParseError
+func fromTokenizerError(err error) *ParseError {
+	if errors.Is(err, tokenizer.ErrNeedMoreData) {
+		return &ParseError{Kind: KindNeedMoreData, Message: err.Error(), Err: err}
+	}
+	var tokErr *tokenizer.Error
+	if !errors.As(err, &tokErr) {
+		return &ParseError{Kind: KindRead, Message: err.Error(), Err: err}
+	}
+
+	kind := KindRead
+	switch tokErr.Kind {
+	case tokenizer.ErrorUnexpectedCharacter:
+		kind = KindUnexpectedCharacter
+	case tokenizer.ErrorUnterminatedString:
+		kind = KindUnterminatedString
+	case tokenizer.ErrorInvalidEscape:
+		kind = KindInvalidEscape
+	case tokenizer.ErrorLoneSurrogate:
+		kind = KindLoneSurrogate
+	case tokenizer.ErrorControlCharacter:
+		kind = KindControlCharacter
+	case tokenizer.ErrorInvalidUTF8:
+		kind = KindInvalidUTF8
+	case tokenizer.ErrorInvalidNumber:
+		kind = KindInvalidNumber
+	case tokenizer.ErrorInvalidLiteral:
+		kind = KindInvalidLiteral
+	case tokenizer.ErrorDocumentTooLarge:
+		kind = KindDocumentTooLarge
+	case tokenizer.ErrorStringTooLong:
+		kind = KindStringTooLong
+	case tokenizer.ErrorNumberTooLong:
+		kind = KindNumberTooLong
+	case tokenizer.ErrorCanceled:
+		kind = KindCanceled
+	case tokenizer.ErrorUnterminatedComment:
+		kind = KindUnterminatedComment
+	}
+	return &ParseError{Kind: kind, Message: tokErr.Message, Pos: tokErr.Pos, Err: err}
+}
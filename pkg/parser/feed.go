@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"io"
+	"json-parser/pkg/tokenizer"
+)
+
+// FeedParser parses a document handed to it in chunks, for callers that
+// cannot block in Read. Every value is reported to the Handler as soon as
+// the chunks fed so far complete it, and Feed returns once they are used up.
+type FeedParser struct {
+	t   *tokenizer.Tokenizer
+	d   *Decoder
+	h   Handler
+	err error // first error, returned from then on
+}
+
+// NewFeedParser parses the input fed to t, which has to be made by
+// tokenizer.NewFeedTokenizer. Input is fed through the parser, not t.
+func NewFeedParser(t *tokenizer.Tokenizer, h Handler) *FeedParser {
+	return NewFeedParserWithOptions(t, h, DefaultOptions())
+}
+
+func NewFeedParserWithOptions(t *tokenizer.Tokenizer, h Handler, opts Options) *FeedParser {
+	reset(h)
+	return &FeedParser{t: t, d: NewDecoderWithOptions(t, opts), h: h}
+}
+
+// Feed parses the next chunk of the document. It returns nil when the chunk
+// is used up without finding a problem, even when the document is not
+// complete yet.
+func (f *FeedParser) Feed(data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.t.Feed(data)
+	return f.run()
+}
+
+// Close marks the end of the document and returns an error when it is
+// incomplete or invalid
+func (f *FeedParser) Close() error {
+	if f.err != nil {
+		return f.err
+	}
+	f.t.Close()
+	return f.run()
+}
+
+// run reports every event the input fed so far completes
+func (f *FeedParser) run() error {
+	for {
+		event, err := f.d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if isNeedMoreData(err) {
+			return nil
+		}
+		if err != nil {
+			f.err = err
+			return f.err
+		}
+		if err := event.emit(f.h); err != nil {
+			f.err = &ParseError{Kind: KindHandler, Message: err.Error(), Pos: event.Pos, Err: err}
+			return f.err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+// Handler receives the structure of a document as the parser walks it, in
+// document order. Returning an error from any method stops parsing; the
+// *ParseError returned by ParseWithHandler then unwraps to that error.
+//
+// Object members are reported as Key followed by the events of the value.
+// Number receives the literal as written in the document, e.g. "-1.5e3".
+type Handler interface {
+	StartObject() error
+	Key(key string) error
+	EndObject() error
+	StartArray() error
+	EndArray() error
+	String(s string) error
+	Number(literal string) error
+	Bool(b bool) error
+	Null() error
+}
+
+// CommentHandler is implemented by a Handler that also wants the comments
+// of the document, which the tokenizer reports with
+// tokenizer.Options.EmitComments set. Comment receives the text between the
+// comment delimiters.
+type CommentHandler interface {
+	Comment(text string) error
+}
+
+// ResetHandler is implemented by a Handler that keeps state between events,
+// such as a half-written document. Reset is called before every document, so
+// a document that failed partway leaves nothing behind for the next one.
+type ResetHandler interface {
+	Reset()
+}
+
+// reset calls Reset when h implements ResetHandler
+func reset(h Handler) {
+	if r, ok := h.(ResetHandler); ok {
+		r.Reset()
+	}
+}
+
+// NopHandler ignores every event. Embed it to implement only the methods a
+// handler cares about.
+type NopHandler struct{}
+
+func (NopHandler) StartObject() error          { return nil }
+func (NopHandler) Key(key string) error        { return nil }
+func (NopHandler) EndObject() error            { return nil }
+func (NopHandler) StartArray() error           { return nil }
+func (NopHandler) EndArray() error             { return nil }
+func (NopHandler) String(s string) error       { return nil }
+func (NopHandler) Number(literal string) error { return nil }
+func (NopHandler) Bool(b bool) error           { return nil }
+func (NopHandler) Null() error                 { return nil }
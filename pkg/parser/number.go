@@ -0,0 +1,326 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDigits caps how many digits converting a number may produce, so a
+// literal like 1e999999999 cannot make BigInt or Decimal allocate gigabytes
+const maxDigits = 100000
+
+var (
+	ErrOverflow   = errors.New("number is out of range")
+	ErrNotInteger = errors.New("number is not an integer")
+	ErrPrecision  = errors.New("number cannot be represented exactly")
+)
+
+// NumberError reports why a Number could not be converted
+type NumberError struct {
+	Literal string
+	Type    string // type converted to, e.g. "int64"
+	Err     error  // ErrOverflow, ErrNotInteger, ErrPrecision or strconv.ErrSyntax
+}
+
+func (e *NumberError) Error() string {
+	return "converting " + e.Literal + " to " + e.Type + ": " + e.Err.Error()
+}
+
+func (e *NumberError) Unwrap() error {
+	return e.Err
+}
+
+// Number is a JSON number kept as its literal, e.g. "-1.5e3", so it can be
+// written back exactly as it was read. Its accessors convert it on demand and
+// report an error instead of silently rounding or wrapping around.
+type Number string
+
+func (n Number) String() string {
+	return string(n)
+}
+
+func (n Number) error(typ string, err error) *NumberError {
+	return &NumberError{Literal: string(n), Type: typ, Err: err}
+}
+
+// nonFinite holds the numbers that JSON5 can write but JSON cannot, and
+// that the tokenizer reads with Options.LenientNumbers
+var nonFinite = map[Number]float64{
+	"Infinity":  math.Inf(1),
+	"-Infinity": math.Inf(-1),
+	"NaN":       math.NaN(),
+}
+
+// IsFinite reports whether the number is neither Infinity, -Infinity nor NaN
+func (n Number) IsFinite() bool {
+	_, ok := nonFinite[n]
+	return !ok
+}
+
+// IsInteger reports whether the literal has neither a fraction nor an
+// exponent. Numbers like 1.0 or 1e3 still convert to integers.
+func (n Number) IsInteger() bool {
+	return !strings.ContainsAny(string(n), ".eE") && n.IsFinite()
+}
+
+// Int64 returns the number as an int64. It fails with ErrNotInteger when the
+// number has a non-zero fraction and with ErrOverflow when it does not fit.
+func (n Number) Int64() (int64, error) {
+	if n.IsInteger() {
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil {
+			return 0, n.error("int64", numError(err))
+		}
+		return i, nil
+	}
+	b, err := n.bigInt("int64")
+	if err != nil {
+		return 0, err
+	}
+	if !b.IsInt64() {
+		return 0, n.error("int64", ErrOverflow)
+	}
+	return b.Int64(), nil
+}
+
+// Uint64 returns the number as a uint64, with the errors of Int64. Negative
+// numbers other than -0 overflow.
+func (n Number) Uint64() (uint64, error) {
+	if n.IsInteger() && !strings.HasPrefix(string(n), "-") {
+		u, err := strconv.ParseUint(string(n), 10, 64)
+		if err != nil {
+			return 0, n.error("uint64", numError(err))
+		}
+		return u, nil
+	}
+	b, err := n.bigInt("uint64")
+	if err != nil {
+		return 0, err
+	}
+	if !b.IsUint64() {
+		return 0, n.error("uint64", ErrOverflow)
+	}
+	return b.Uint64(), nil
+}
+
+// Float64 returns the nearest float64. It fails with ErrOverflow, returning
+// ±Inf, when the number is beyond the largest float64, and with
+// ErrPrecision, returning the nearest float64, when that float64 does not
+// read back as the same number: 0.1 converts, 9007199254740993 does not.
+// Infinity, -Infinity and NaN convert to the float64 they name.
+func (n Number) Float64() (float64, error) {
+	if f, ok := nonFinite[n]; ok {
+		return f, nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return f, n.error("float64", numError(err))
+	}
+	same, err := n.equal(Number(strconv.FormatFloat(f, 'g', -1, 64)))
+	if err != nil {
+		return f, n.error("float64", err)
+	}
+	if !same {
+		return f, n.error("float64", ErrPrecision)
+	}
+	return f, nil
+}
+
+// BigInt returns the number as an arbitrary precision integer. It fails
+// with ErrNotInteger when the number has a non-zero fraction.
+func (n Number) BigInt() (*big.Int, error) {
+	return n.bigInt("big.Int")
+}
+
+func (n Number) bigInt(typ string) (*big.Int, error) {
+	negative, digits, exponent, err := n.normalized()
+	if err != nil {
+		return nil, n.error(typ, err)
+	}
+	if exponent < 0 {
+		return nil, n.error(typ, ErrNotInteger)
+	}
+	if len(digits)+exponent > maxDigits {
+		return nil, n.error(typ, ErrOverflow)
+	}
+	b := new(big.Int)
+	if digits != "" {
+		b.SetString(digits+strings.Repeat("0", exponent), 10)
+	}
+	if negative {
+		b.Neg(b)
+	}
+	return b, nil
+}
+
+// BigFloat returns the number as an arbitrary precision float, with enough
+// precision to keep every significant digit of the literal. It fails with
+// ErrOverflow when the exponent is beyond what big.Float supports, too large
+// or too small. Infinity and -Infinity convert to the infinities of
+// big.Float, NaN fails as big.Float has none.
+func (n Number) BigFloat() (*big.Float, error) {
+	if f, ok := nonFinite[n]; ok && !math.IsNaN(f) {
+		return new(big.Float).SetInf(f < 0), nil
+	}
+	_, digits, _, err := n.normalized()
+	if err != nil {
+		return nil, n.error("big.Float", err)
+	}
+	// log2(10) is just below 4 bits per decimal digit
+	prec := uint(4*len(digits) + 64)
+	f, _, err := big.ParseFloat(string(n), 10, prec, big.ToNearestEven)
+	if err != nil || f.IsInf() || f.Sign() == 0 && digits != "" {
+		return nil, n.error("big.Float", ErrOverflow)
+	}
+	return f, nil
+}
+
+// Decimal is the exact value Coefficient × 10^Exponent. Trailing zeros of
+// the literal are kept, so 1.50 is 150 × 10^-2.
+type Decimal struct {
+	Coefficient *big.Int
+	Exponent    int
+}
+
+// Decimal returns the exact value of the number. It fails with ErrOverflow
+// when the exponent is too large to be expanded safely.
+func (n Number) Decimal() (Decimal, error) {
+	negative, digits, exponent, err := n.parts()
+	if err != nil {
+		return Decimal{}, n.error("decimal", err)
+	}
+	if exponent > maxDigits || exponent < -maxDigits {
+		return Decimal{}, n.error("decimal", ErrOverflow)
+	}
+	coefficient := new(big.Int)
+	if digits != "" {
+		coefficient.SetString(digits, 10)
+	}
+	if negative {
+		coefficient.Neg(coefficient)
+	}
+	return Decimal{Coefficient: coefficient, Exponent: exponent}, nil
+}
+
+// Rat returns the decimal as a fraction
+func (d Decimal) Rat() *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(d.Exponent))), nil)
+	if d.Exponent >= 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(d.Coefficient, scale))
+	}
+	return new(big.Rat).SetFrac(d.Coefficient, scale)
+}
+
+// String formats the decimal without an exponent, e.g. "-12.50"
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.Coefficient).String()
+	sign := ""
+	if d.Coefficient.Sign() < 0 {
+		sign = "-"
+	}
+	if d.Exponent >= 0 {
+		if digits == "0" {
+			return "0"
+		}
+		return sign + digits + strings.Repeat("0", d.Exponent)
+	}
+	if scale := -d.Exponent; len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	point := len(digits) + d.Exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// parts splits the literal into its sign, its significant digits without
+// leading zeros ("" for zero) and the power of ten they are multiplied by
+func (n Number) parts() (negative bool, digits string, exponent int, err error) {
+	s := string(n)
+	if strings.HasPrefix(s, "-") {
+		negative, s = true, s[1:]
+	}
+
+	mantissa, exp, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	integer, fraction, hasFraction := strings.Cut(mantissa, ".")
+	if !isDigits(integer) || (len(integer) > 1 && integer[0] == '0') || (hasFraction && !isDigits(fraction)) {
+		return false, "", 0, strconv.ErrSyntax
+	}
+	if hasExponent {
+		sign := 1
+		if strings.HasPrefix(exp, "-") {
+			sign, exp = -1, exp[1:]
+		} else {
+			exp = strings.TrimPrefix(exp, "+")
+		}
+		if !isDigits(exp) {
+			return false, "", 0, strconv.ErrSyntax
+		}
+		e, err := strconv.Atoi(exp)
+		if err != nil || e > math.MaxInt32 {
+			return false, "", 0, ErrOverflow
+		}
+		exponent = sign * e
+	}
+
+	digits = strings.TrimLeft(integer+fraction, "0")
+	exponent -= len(fraction)
+	// Zero has no sign, but keeps its scale
+	return negative && digits != "", digits, exponent, nil
+}
+
+// normalized is parts without trailing zeros in digits, so equal numbers
+// have equal parts
+func (n Number) normalized() (negative bool, digits string, exponent int, err error) {
+	negative, digits, exponent, err = n.parts()
+	if digits == "" {
+		return false, "", 0, err
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	return negative, trimmed, exponent + len(digits) - len(trimmed), err
+}
+
+// equal reports whether n and m are the same number, however written
+func (n Number) equal(m Number) (bool, error) {
+	nNegative, nDigits, nExponent, err := n.normalized()
+	if err != nil {
+		return false, err
+	}
+	mNegative, mDigits, mExponent, err := m.normalized()
+	if err != nil {
+		return false, err
+	}
+	return nNegative == mNegative && nDigits == mDigits && nExponent == mExponent, nil
+}
+
+// numError turns the error of a strconv conversion into ours
+func numError(err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return ErrOverflow
+	}
+	return strconv.ErrSyntax
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
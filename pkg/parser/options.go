@@ -0,0 +1,148 @@
+package parser
+
+import "json-parser/pkg/tokenizer"
+
+// DuplicateKeyPolicy decides what happens when an object holds the same key
+// more than once
+type DuplicateKeyPolicy int
+
+const (
+	DuplicateKeepAll   DuplicateKeyPolicy = iota // keep every member, Value.GetAll returns them all
+	DuplicateReject                              // reject the document
+	DuplicateWarn                                // report through OnWarning and keep every member
+	DuplicateKeepFirst                           // keep the first member and drop the others
+	DuplicateKeepLast                            // keep the last value, at the first member's place
+)
+
+var duplicateKeyPolicyNames = map[DuplicateKeyPolicy]string{
+	DuplicateKeepAll:   "all",
+	DuplicateReject:    "reject",
+	DuplicateWarn:      "warn",
+	DuplicateKeepFirst: "first",
+	DuplicateKeepLast:  "last",
+}
+
+func (d DuplicateKeyPolicy) String() string {
+	if name, ok := duplicateKeyPolicyNames[d]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseDuplicateKeyPolicy returns the policy named by String
+func ParseDuplicateKeyPolicy(name string) (DuplicateKeyPolicy, bool) {
+	for policy, policyName := range duplicateKeyPolicyNames {
+		if policyName == name {
+			return policy, true
+		}
+	}
+	return 0, false
+}
+
+// Profile is a set of rules on top of RFC 8259 that a document has to follow
+type Profile int
+
+const (
+	ProfileNone Profile = iota
+	// ProfileIJSON is I-JSON (RFC 7493): an object or array as the document,
+	// no duplicate keys, no invalid UTF-8, surrogates or noncharacters in
+	// strings, and only numbers a double holds exactly, integers within
+	// ±(2^53-1). The tokenizer reads strict JSON whatever its options say;
+	// the Parse functions restore its options once they return, a Decoder,
+	// DocumentReader or FeedParser keeps them strict.
+	ProfileIJSON
+)
+
+var profileNames = map[Profile]string{
+	ProfileNone:  "none",
+	ProfileIJSON: "i-json",
+}
+
+func (p Profile) String() string {
+	if name, ok := profileNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseProfile returns the profile named by String
+func ParseProfile(name string) (Profile, bool) {
+	for profile, profileName := range profileNames {
+		if profileName == name {
+			return profile, true
+		}
+	}
+	return 0, false
+}
+
+// Options configures a parse. Each of the limits guards against a different
+// way untrusted input can exhaust resources; zero means no limit.
+type Options struct {
+	// RFC4627 restricts the document to an object or an array, as required
+	// by RFC 4627, instead of accepting any value as RFC 8259 does
+	RFC4627 bool
+	// Profile adds its rules to the other options, overriding them where
+	// they allow less
+	Profile Profile
+	// TrailingCommas accepts a comma after the last element of an array or
+	// member of an object. It is always on when the tokenizer reads a
+	// dialect other than JSON.
+	TrailingCommas bool
+
+	DuplicateKeys DuplicateKeyPolicy
+	// OnWarning, when set, receives problems that do not reject the document
+	OnWarning func(warning *ParseError)
+	// CheckPrecision warns about every number that changes when converted to
+	// a float64 and back, such as integers beyond 2^53
+	CheckPrecision bool
+
+	MaxDepth        int   // nesting of arrays and objects
+	MaxBytes        int64 // size of the whole document
+	MaxStringLength int   // bytes between the quotes of a string
+	MaxNumberLength int   // characters of a number literal
+	MaxMembers      int   // members of one object or elements of one array
+	MaxTokens       int   // tokens in the whole document
+
+	identifierKeys bool // object keys may be identifiers, set by configure
+	optionalCommas bool // members and elements may be separated by line breaks alone, set by configure
+}
+
+// DefaultOptions returns the options used by Parse and ParseValue
+func DefaultOptions() Options {
+	return Options{
+		MaxDepth: 512,
+	}
+}
+
+func (o Options) tokenizerLimits() tokenizer.Limits {
+	return tokenizer.Limits{
+		MaxBytes:        o.MaxBytes,
+		MaxStringLength: o.MaxStringLength,
+		MaxNumberLength: o.MaxNumberLength,
+	}
+}
+
+// configure applies the options to t and returns them with the rules of the
+// dialect and the profile filled in
+func (o Options) configure(t *tokenizer.Tokenizer) Options {
+	if t.Options().Dialect != tokenizer.DialectJSON {
+		o.TrailingCommas = true
+	}
+	o.identifierKeys = t.Options().Dialect == tokenizer.DialectJSON5
+	o.optionalCommas = t.Options().Dialect == tokenizer.DialectHjson
+	if o.Profile == ProfileIJSON {
+		o.RFC4627 = true
+		o.TrailingCommas = false
+		o.identifierKeys = false
+		o.optionalCommas = false
+		o.DuplicateKeys = DuplicateReject
+		strict := t.Options()
+		strict.LoneSurrogates = tokenizer.SurrogateError
+		strict.InvalidUTF8 = tokenizer.UTF8Error
+		strict.Dialect = tokenizer.DialectJSON
+		strict.LenientNumbers = false
+		t.SetOptions(strict)
+	}
+	t.SetLimits(o.tokenizerLimits())
+	return o
+}
@@ -1,342 +1,517 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"json-parser/pkg/tokenizer"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
-func Parse(t *tokenizer.Tokenizer) bool {
-	stack := []tokenizer.TokenType{}
-	processedCount := 0
-	nestingCount := 0
-	const maxNestingDepth = 19
-	for {
-		token, err := t.NextToken()
-		if err != nil {
-			fmt.Println("Error: ", err)
-			return false
-		}
+// Parse validates the token stream produced by t. It returns nil for a valid
+// document and a *ParseError describing the first problem otherwise.
+func Parse(t *tokenizer.Tokenizer) error {
+	return ParseWithOptions(t, DefaultOptions())
+}
 
-		switch token.Type {
-		case tokenizer.TokenLeftBrace:
-			stack, processedCount = handleLeftBrace(stack, processedCount)
-			nestingCount++
-			if nestingCount > maxNestingDepth {
-				fmt.Println("Reached maximum nesting limit")
-				return false
-			}
+func ParseWithOptions(t *tokenizer.Tokenizer, opts Options) error {
+	return parse(t, NopHandler{}, opts)
+}
 
-		case tokenizer.TokenRightBrace:
-			var isSuccess bool
-			stack, processedCount, isSuccess = handleRightBrace(stack, processedCount)
-			if !isSuccess {
-				return false
-			}
-			nestingCount--
+// ParseWithHandler walks the token stream produced by t, reporting every
+// value to h as it is accepted. Events already delivered stay delivered when
+// a later part of the document turns out to be invalid.
+func ParseWithHandler(t *tokenizer.Tokenizer, h Handler, opts Options) error {
+	return parse(t, h, opts)
+}
 
-		case tokenizer.TokenLeftSquare:
-			stack, processedCount = handleLeftSquare(stack, processedCount)
-			nestingCount++
-			if nestingCount > maxNestingDepth {
-				fmt.Println("Reached maximum nesting limit")
-				return false
-			}
+// ParseValue parses the token stream produced by t into a document tree
+func ParseValue(t *tokenizer.Tokenizer) (*Value, error) {
+	return ParseValueWithOptions(t, DefaultOptions())
+}
 
-		case tokenizer.TokenRightSquare:
-			var isSuccess bool
-			stack, processedCount, isSuccess = handleRightSquare(stack, processedCount)
-			if !isSuccess {
-				return false
-			}
-			nestingCount--
+func ParseValueWithOptions(t *tokenizer.Tokenizer, opts Options) (*Value, error) {
+	b := &builder{duplicates: opts.DuplicateKeys}
+	if err := parse(t, b, opts); err != nil {
+		return nil, err
+	}
+	return b.root, nil
+}
 
-		case tokenizer.TokenString:
-			var isSuccess bool
-			stack, isSuccess = handleString(stack, token)
-			if !isSuccess {
-				return false
-			}
+// ParseContext is Parse that gives up once ctx is done, returning a
+// *ParseError of kind KindCanceled positioned where parsing stopped. It
+// unwraps to ctx.Err().
+func ParseContext(ctx context.Context, t *tokenizer.Tokenizer) error {
+	return ParseContextWithOptions(ctx, t, DefaultOptions())
+}
 
-		case tokenizer.TokenColon:
-			var isSuccess bool
-			stack, isSuccess = handleColon(stack)
-			if !isSuccess {
-				return false
-			}
+func ParseContextWithOptions(ctx context.Context, t *tokenizer.Tokenizer, opts Options) error {
+	t.SetContext(ctx)
+	defer t.SetContext(context.Background())
+	return ParseWithOptions(t, opts)
+}
 
-		case tokenizer.TokenComma:
-			var isSuccess bool
-			stack, isSuccess = handleComma(stack)
-			if !isSuccess {
-				return false
-			}
+// ParseValueContext is ParseValue that gives up once ctx is done, like
+// ParseContext
+func ParseValueContext(ctx context.Context, t *tokenizer.Tokenizer) (*Value, error) {
+	return ParseValueContextWithOptions(ctx, t, DefaultOptions())
+}
 
-		case tokenizer.TokenNull:
-			var isSuccess bool
-			stack, isSuccess = handleNull(stack, token)
-			if !isSuccess {
-				return false
-			}
+func ParseValueContextWithOptions(ctx context.Context, t *tokenizer.Tokenizer, opts Options) (*Value, error) {
+	t.SetContext(ctx)
+	defer t.SetContext(context.Background())
+	return ParseValueWithOptions(t, opts)
+}
 
-		case tokenizer.TokenTrue:
-			var isSuccess bool
-			stack, isSuccess = handleTrue(stack, token)
-			if !isSuccess {
-				return false
-			}
+// valueTokens are the tokens a value can start with
+var valueTokens = []tokenizer.TokenType{
+	tokenizer.TokenLeftBrace,
+	tokenizer.TokenLeftSquare,
+	tokenizer.TokenString,
+	tokenizer.TokenNumber,
+	tokenizer.TokenTrue,
+	tokenizer.TokenFalse,
+	tokenizer.TokenNull,
+}
 
-		case tokenizer.TokenFalse:
-			var isSuccess bool
-			stack, isSuccess = handleFalse(stack, token)
-			if !isSuccess {
-				return false
-			}
+// parser is a recursive-descent parser over the JSON grammar:
+//
+//	document = value EOF              (object or array with RFC4627 set)
+//	value    = object | array | string | number | true | false | null
+//	object   = '{' [ member { ',' member } ] '}'
+//	member   = string ':' value
+//	array    = '[' [ element { ',' element } ] ']'
+//	element  = value
+//
+// With TrailingCommas set the last member or element may be followed by ','.
+// JSON5 and Hjson members may also start with an identifier instead of a
+// string, and Hjson lets the commas between them be left out.
+//
+// It looks at exactly one token at a time, p.token, and reporting a token to
+// the handler is the same step as moving past it.
+type parser struct {
+	t      *tokenizer.Tokenizer
+	h      Handler
+	opts   Options
+	token  tokenizer.Token // current token
+	tokens int             // tokens read so far
+	depth  int             // arrays and objects currently open
+}
 
-		case tokenizer.TokenNumber:
-			var isSuccess bool
-			stack, isSuccess = handleNumber(stack, token)
-			if !isSuccess {
-				return false
-			}
+// parse validates the token stream, reporting every accepted value to h
+func parse(t *tokenizer.Tokenizer, h Handler, opts Options) error {
+	// A profile only makes the tokenizer strict for this document
+	original := t.Options()
+	defer t.SetOptions(original)
+	opts = opts.configure(t)
+	p := &parser{t: t, h: h, opts: opts}
+	reset(h)
+	if err := p.parseDocument(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *parser) parseDocument() *ParseError {
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	// RFC 8259 allows any value as the document, RFC 4627 only an object or
+	// an array
+	if p.opts.RFC4627 && p.token.Type != tokenizer.TokenLeftBrace && p.token.Type != tokenizer.TokenLeftSquare {
+		return p.unexpected(tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare)
+	}
+	if err := p.parseValue(); err != nil {
+		return err
+	}
 
-		case tokenizer.TokenEOF:
-			return handleEOF(stack, processedCount)
+	if p.token.Type != tokenizer.TokenEOF {
+		return p.unexpected(tokenizer.TokenEOF)
+	}
+	return nil
+}
 
+// level is an array or object parseValue is inside of
+type level struct {
+	object  bool
+	members int                           // members or elements so far
+	seen    map[string]tokenizer.Position // keys, when duplicates are reported
+}
+
+// parseValue parses the value starting at the current token. Arrays and
+// objects are followed with a stack of their own rather than recursion, so
+// nesting is only bounded by MaxDepth.
+func (p *parser) parseValue() *ParseError {
+	var stack []level
+	for {
+		switch p.token.Type {
+		case tokenizer.TokenLeftBrace:
+			if err := p.enter(); err != nil {
+				return err
+			}
+			if p.token.Type == tokenizer.TokenRightBrace {
+				if err := p.leave(); err != nil {
+					return err
+				}
+				break
+			}
+			if _, ok := p.key(); !ok {
+				return p.unexpected(append(p.keyTokens(), tokenizer.TokenRightBrace)...)
+			}
+			// Keys are only remembered when duplicates have to be reported
+			top := level{object: true, members: 1}
+			if p.opts.DuplicateKeys == DuplicateReject || p.opts.DuplicateKeys == DuplicateWarn {
+				top.seen = map[string]tokenizer.Position{}
+			}
+			if err := p.checkMembers(top.members); err != nil {
+				return err
+			}
+			if err := p.parseKey(top.seen); err != nil {
+				return err
+			}
+			stack = append(stack, top)
+			continue
+		case tokenizer.TokenLeftSquare:
+			if err := p.enter(); err != nil {
+				return err
+			}
+			if p.token.Type == tokenizer.TokenRightSquare {
+				if err := p.leave(); err != nil {
+					return err
+				}
+				break
+			}
+			if !slices.Contains(valueTokens, p.token.Type) {
+				return p.unexpected(slices.Concat(valueTokens, []tokenizer.TokenType{tokenizer.TokenRightSquare})...)
+			}
+			if err := p.checkMembers(1); err != nil {
+				return err
+			}
+			stack = append(stack, level{members: 1})
+			continue
+		case tokenizer.TokenString, tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull:
+			if err := p.consume(); err != nil {
+				return err
+			}
 		default:
-			fmt.Printf("Unexpected token type: %d\n", token.Type)
+			return p.unexpected(valueTokens...)
+		}
+
+		// A value is complete, close the containers it completes and move on
+		// to the next member or element
+		if err := p.next(&stack); err != nil || len(stack) == 0 {
+			return err
 		}
 	}
 }
 
-func handleLeftBrace(stack []tokenizer.TokenType, processedCount int) ([]tokenizer.TokenType, int) {
-	// Opening braces are always appended directly
-	stack = append(stack, tokenizer.TokenLeftBrace)
-	processedCount++
-	return stack, processedCount
+// next moves past what follows a value inside the innermost container of
+// stack: the containers that end, and the comma and key before the next
+// member or element. The stack is empty once the outermost one ended.
+func (p *parser) next(stack *[]level) *ParseError {
+	for len(*stack) > 0 {
+		top := &(*stack)[len(*stack)-1]
+		closing := tokenizer.TokenRightSquare
+		if top.object {
+			closing = tokenizer.TokenRightBrace
+		}
+
+		switch p.token.Type {
+		case tokenizer.TokenComma:
+			if err := p.consume(); err != nil {
+				return err
+			}
+			if p.opts.TrailingCommas && p.token.Type == closing {
+				if err := p.leave(); err != nil {
+					return err
+				}
+				*stack = (*stack)[:len(*stack)-1]
+				continue
+			}
+		case closing:
+			if err := p.leave(); err != nil {
+				return err
+			}
+			*stack = (*stack)[:len(*stack)-1]
+			continue
+		default:
+			if !p.opts.optionalCommas {
+				return p.unexpected(tokenizer.TokenComma, closing)
+			}
+		}
+
+		top.members++
+		if err := p.checkMembers(top.members); err != nil {
+			return err
+		}
+		if top.object {
+			return p.parseKey(top.seen)
+		}
+		return nil
+	}
+	return nil
 }
 
-func handleRightBrace(stack []tokenizer.TokenType, processedCount int) ([]tokenizer.TokenType, int, bool) {
-	// Right brace can only follow a KeyValue or a LeftBrace
-	if len(stack) == 0 || (stack[len(stack)-1] != tokenizer.TokenKeyValue && stack[len(stack)-1] != tokenizer.TokenLeftBrace) {
-		fmt.Println("Error: Reached Right brace prematurely")
-		return stack, processedCount, false
+// parseKey parses the key and colon of an object member
+func (p *parser) parseKey(seen map[string]tokenizer.Position) *ParseError {
+	key, ok := p.key()
+	if !ok {
+		return p.unexpected(p.keyTokens()...)
+	}
+	if seen != nil {
+		if previous, ok := seen[key]; ok {
+			if err := p.duplicateKey(previous); err != nil {
+				return err
+			}
+		} else {
+			seen[key] = p.token.Start
+		}
+	}
+	if err := p.h.Key(key); err != nil {
+		return p.handlerError(err)
+	}
+	if err := p.advance(); err != nil {
+		return err
 	}
 
-	// Remove any KeyValue(s) to reach the LeftBrace
-	for len(stack) > 0 && stack[len(stack)-1] == tokenizer.TokenKeyValue {
-		stack = stack[:len(stack)-1]
+	if p.token.Type != tokenizer.TokenColon {
+		return p.unexpected(tokenizer.TokenColon)
 	}
+	return p.consume()
+}
 
-	// If no LeftBrace is found throw error
-	if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenLeftBrace {
-		fmt.Println("Error: cannot find corresponding left brace")
-		return stack, processedCount, false
+// key returns the object key spelled by the current token. JSON5 keys may
+// also be identifiers, including those spelling true, false, null, Infinity
+// and NaN.
+func (p *parser) key() (string, bool) {
+	switch p.token.Type {
+	case tokenizer.TokenString, tokenizer.TokenIdentifier:
+		return p.token.Value, true
+	case tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull, tokenizer.TokenNumber:
+		if p.opts.identifierKeys && unicode.IsLetter(rune(p.token.Raw[0])) {
+			return p.token.Raw, true
+		}
 	}
+	return "", false
+}
 
-	// Remove LeftBrace
-	stack = stack[:len(stack)-1]
+// keyTokens returns the tokens an object key can be
+func (p *parser) keyTokens() []tokenizer.TokenType {
+	if p.opts.identifierKeys {
+		return []tokenizer.TokenType{tokenizer.TokenString, tokenizer.TokenIdentifier}
+	}
+	return []tokenizer.TokenType{tokenizer.TokenString}
+}
 
-	// If a Colon is found, append a KeyValue
-	if len(stack) != 0 && stack[len(stack)-1] == tokenizer.TokenColon && stack[len(stack)-2] == tokenizer.TokenString {
-		stack = stack[:len(stack)-2]
-		stack = append(stack, tokenizer.TokenKeyValue)
+// duplicateKey reports the current key, already defined at previous, as an
+// error or a warning depending on the policy
+func (p *parser) duplicateKey(previous tokenizer.Position) *ParseError {
+	err := newParseError(KindDuplicateKey, p.token, fmt.Sprintf("duplicate key %s, first defined at %s", p.token.Raw, previous))
+	err.Previous = previous
+	if p.opts.DuplicateKeys == DuplicateReject {
+		return err
 	}
+	if p.opts.OnWarning != nil {
+		p.opts.OnWarning(err)
+	}
+	return nil
+}
 
-	// If it's an array element, append an Object
-	if len(stack) != 0 && stack[len(stack)-1] == tokenizer.TokenComma {
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenObject)
+// enter moves past the token opening an array or object
+func (p *parser) enter() *ParseError {
+	if err := p.nest(); err != nil {
+		return err
 	}
+	return p.consume()
+}
 
-	processedCount++
-	return stack, processedCount, true
+// nest counts the array or object opened by the current token
+func (p *parser) nest() *ParseError {
+	p.depth++
+	if p.opts.MaxDepth > 0 && p.depth > p.opts.MaxDepth {
+		return newParseError(KindNestingLimit, p.token, "reached maximum nesting limit")
+	}
+	return nil
 }
 
-func handleLeftSquare(stack []tokenizer.TokenType, processedCount int) ([]tokenizer.TokenType, int) {
-	// Opening braces are always appended directly
-	stack = append(stack, tokenizer.TokenLeftSquare)
-	processedCount++
-	return stack, processedCount
+// leave moves past the token closing an array or object
+func (p *parser) leave() *ParseError {
+	p.depth--
+	return p.consume()
 }
 
-func handleRightSquare(stack []tokenizer.TokenType, processedCount int) ([]tokenizer.TokenType, int, bool) {
-	// Right square cannot follow a comma, left brace, right brace, colon, or key value
-	if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenComma || stack[len(stack)-1] == tokenizer.TokenLeftBrace || stack[len(stack)-1] == tokenizer.TokenRightBrace || stack[len(stack)-1] == tokenizer.TokenColon || stack[len(stack)-1] == tokenizer.TokenKeyValue) {
-		fmt.Println("Error: Reached right square brace prematurely")
-		return stack, processedCount, false
+func (p *parser) checkMembers(members int) *ParseError {
+	if p.opts.MaxMembers > 0 && members > p.opts.MaxMembers {
+		return newParseError(KindTooManyMembers, p.token, fmt.Sprintf("container exceeds the limit of %d members", p.opts.MaxMembers))
 	}
+	return nil
+}
 
-	// Remove all objects to reach a left square
-	for len(stack) > 0 && stack[len(stack)-1] != tokenizer.TokenLeftSquare {
-		stack = stack[:len(stack)-1]
+// consume reports the current token to the handler and reads the next one.
+// Keys are reported by parseKey, commas and colons are not reported.
+func (p *parser) consume() *ParseError {
+	var err error
+	switch p.token.Type {
+	case tokenizer.TokenLeftBrace:
+		err = p.h.StartObject()
+	case tokenizer.TokenRightBrace:
+		err = p.h.EndObject()
+	case tokenizer.TokenLeftSquare:
+		err = p.h.StartArray()
+	case tokenizer.TokenRightSquare:
+		err = p.h.EndArray()
+	case tokenizer.TokenString:
+		err = p.h.String(p.token.Value)
+	case tokenizer.TokenNumber:
+		err = p.h.Number(p.token.Value)
+	case tokenizer.TokenTrue:
+		err = p.h.Bool(true)
+	case tokenizer.TokenFalse:
+		err = p.h.Bool(false)
+	case tokenizer.TokenNull:
+		err = p.h.Null()
 	}
-
-	// If no left square is found throw error
-	if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenLeftSquare {
-		fmt.Println("Error: cannot find corresponding left square brace")
-		return stack, processedCount, false
+	if err != nil {
+		return p.handlerError(err)
 	}
+	return p.advance()
+}
 
-	// Remove left square
-	stack = stack[:len(stack)-1]
+// handlerError wraps an error returned by the handler for the current token
+func (p *parser) handlerError(err error) *ParseError {
+	parseErr := newParseError(KindHandler, p.token, err.Error())
+	parseErr.Err = err
+	return parseErr
+}
 
-	// If a Colon is found, append a KeyValue
-	if len(stack) != 0 && stack[len(stack)-1] == tokenizer.TokenColon && stack[len(stack)-2] == tokenizer.TokenString {
-		stack = stack[:len(stack)-2]
-		stack = append(stack, tokenizer.TokenKeyValue)
+// advance reads the next token, passing comments on to a CommentHandler
+func (p *parser) advance() *ParseError {
+	token, err := p.t.NextToken()
+	for err == nil && token.Type == tokenizer.TokenComment {
+		if h, ok := p.h.(CommentHandler); ok {
+			if err := h.Comment(token.Value); err != nil {
+				p.token = token
+				return p.handlerError(err)
+			}
+		}
+		token, err = p.t.NextToken()
 	}
-
-	// If it's an array element, append an Array
-	if len(stack) != 0 && stack[len(stack)-1] == tokenizer.TokenComma {
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenArray)
+	if err != nil {
+		return fromTokenizerError(err)
 	}
 
-	processedCount++
-	return stack, processedCount, true
+	// Framing tokens belong to no document
+	if token.Type != tokenizer.TokenEOF && token.Type != tokenizer.TokenNewline && token.Type != tokenizer.TokenRecordSeparator {
+		p.tokens++
+		if p.opts.MaxTokens > 0 && p.tokens > p.opts.MaxTokens {
+			return newParseError(KindTooManyTokens, token, fmt.Sprintf("document exceeds the limit of %d tokens", p.opts.MaxTokens))
+		}
+	}
+	if p.opts.Profile == ProfileIJSON {
+		if err := p.checkIJSON(token); err != nil {
+			return err
+		}
+	} else if token.Type == tokenizer.TokenNumber && p.opts.CheckPrecision && p.opts.OnWarning != nil {
+		p.checkPrecision(token)
+	}
+	p.token = token
+	return nil
 }
 
-func handleString(stack []tokenizer.TokenType, token tokenizer.Token) ([]tokenizer.TokenType, bool) {
-	// KEY String (or Array string) can follow either a comma, left brace, or left square
-	if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenComma || stack[len(stack)-1] == tokenizer.TokenLeftBrace || stack[len(stack)-1] == tokenizer.TokenLeftSquare) {
-		if stack[len(stack)-1] == tokenizer.TokenComma {
-			stack = stack[:len(stack)-1]
+// maxSafeInteger is 2^53-1, the largest integer I-JSON allows
+var maxSafeInteger = big.NewInt(1<<53 - 1)
+
+// checkIJSON rejects strings and numbers that I-JSON does not allow
+func (p *parser) checkIJSON(token tokenizer.Token) *ParseError {
+	switch token.Type {
+	case tokenizer.TokenString:
+		for _, r := range token.Value {
+			if isNoncharacter(r) {
+				return newParseError(KindProfile, token, fmt.Sprintf("I-JSON does not allow the noncharacter U+%04X", r))
+			}
 		}
-		stack = append(stack, tokenizer.TokenString)
-		return stack, true
-		// Key-Value String can only follow a colon
-	} else if len(stack) > 0 && stack[len(stack)-1] == tokenizer.TokenColon {
-		stack = stack[:len(stack)-1]
-		if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenString {
-			fmt.Println("Error: could not find corresponding key for value: ", token.Value)
-			return stack, false
+	case tokenizer.TokenNumber:
+		n := Number(token.Value)
+		if n.IsInteger() {
+			if b, err := n.BigInt(); err != nil || b.CmpAbs(maxSafeInteger) > 0 {
+				return newParseError(KindProfile, token, fmt.Sprintf("I-JSON only allows integers within ±(2^53-1), not %s", token.Raw))
+			}
+		} else if _, err := n.Float64(); err != nil {
+			return newParseError(KindProfile, token, fmt.Sprintf("I-JSON only allows numbers a double holds exactly, not %s", token.Raw))
 		}
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenKeyValue)
-		return stack, true
-	} else {
-		fmt.Println("Error: found invalid string:", token.Value)
-		return stack, false
 	}
+	return nil
 }
 
-func handleColon(stack []tokenizer.TokenType) ([]tokenizer.TokenType, bool) {
-	// Colon can only follow a String
-	if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenString {
-		fmt.Println("Error: found Colon prematurely")
-		fmt.Println(stack)
-		return stack, false
-	}
-	stack = append(stack, tokenizer.TokenColon)
-	return stack, true
+// isNoncharacter reports whether r is one of the 66 code points Unicode
+// reserves for internal use
+func isNoncharacter(r rune) bool {
+	return (r >= 0xFDD0 && r <= 0xFDEF) || r&0xFFFE == 0xFFFE
 }
 
-func handleComma(stack []tokenizer.TokenType) ([]tokenizer.TokenType, bool) {
-	// Comma can follow anything expect right/left (square) braces
-	if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenLeftBrace || stack[len(stack)-1] == tokenizer.TokenRightBrace || stack[len(stack)-1] == tokenizer.TokenLeftSquare || stack[len(stack)-1] == tokenizer.TokenRightSquare) {
-		fmt.Println("Error: Invalid Comma")
-		return stack, false
+// checkPrecision warns about a number that reads back differently once it
+// went through a float64, as it does in JavaScript
+func (p *parser) checkPrecision(token tokenizer.Token) {
+	f, err := Number(token.Value).Float64()
+	if err == nil {
+		return
 	}
-	stack = append(stack, tokenizer.TokenComma)
-	return stack, true
+	message := fmt.Sprintf("number %s becomes %s as a float64", token.Raw, formatJavaScript(f))
+	p.opts.OnWarning(newParseError(KindPrecisionLoss, token, message))
 }
 
-func handleNull(stack []tokenizer.TokenType, token tokenizer.Token) ([]tokenizer.TokenType, bool) {
-	// Null as a Value
-	if len(stack) > 0 && stack[len(stack)-1] == tokenizer.TokenColon {
-		stack = stack[:len(stack)-1]
-		if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenString {
-			fmt.Println("Error: could not find corresponding key for value: ", token.Value)
-			return stack, false
-		}
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenKeyValue)
-		return stack, true
-		// Null as an array element
-	} else if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenComma || stack[len(stack)-1] == tokenizer.TokenLeftSquare) {
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenNull)
-		return stack, true
-	} else {
-		fmt.Println("Error: found invalid null")
-		return stack, false
-	}
-}
-
-func handleTrue(stack []tokenizer.TokenType, token tokenizer.Token) ([]tokenizer.TokenType, bool) {
-	// True as a Value
-	if len(stack) > 0 && stack[len(stack)-1] == tokenizer.TokenColon {
-		stack = stack[:len(stack)-1]
-		if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenString {
-			fmt.Println("Error: could not find corresponding key for value: ", token.Value)
-			return stack, false
-		}
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenKeyValue)
-		return stack, true
-		// True as an array element
-	} else if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenComma || stack[len(stack)-1] == tokenizer.TokenLeftSquare) {
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenTrue)
-		return stack, true
-	} else {
-		fmt.Println("Error: found invalid true")
-		return stack, false
-	}
-}
-
-func handleFalse(stack []tokenizer.TokenType, token tokenizer.Token) ([]tokenizer.TokenType, bool) {
-	// False as a Value
-	if len(stack) > 0 && stack[len(stack)-1] == tokenizer.TokenColon {
-		stack = stack[:len(stack)-1]
-		if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenString {
-			fmt.Println("Error: could not find corresponding key for value: ", token.Value)
-			return stack, false
-		}
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenKeyValue)
-		return stack, true
-		// False as an array element
-	} else if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenComma || stack[len(stack)-1] == tokenizer.TokenLeftSquare) {
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenFalse)
-		return stack, true
-	} else {
-		fmt.Println("Error: found invalid false")
-		return stack, false
-	}
-}
-
-func handleNumber(stack []tokenizer.TokenType, token tokenizer.Token) ([]tokenizer.TokenType, bool) {
-	// Number as a Value
-	if len(stack) > 0 && stack[len(stack)-1] == tokenizer.TokenColon {
-		stack = stack[:len(stack)-1]
-		if len(stack) == 0 || stack[len(stack)-1] != tokenizer.TokenString {
-			fmt.Println("Error: could not find corresponding key for value: ", token.Value)
-			return stack, false
-		}
-		stack = stack[:len(stack)-1]
-		stack = append(stack, tokenizer.TokenKeyValue)
-		return stack, true
-		// Number as an array element
-	} else if len(stack) > 0 && (stack[len(stack)-1] == tokenizer.TokenComma || stack[len(stack)-1] == tokenizer.TokenLeftSquare) {
-		if stack[len(stack)-1] == tokenizer.TokenComma {
-			stack = stack[:len(stack)-1]
+// formatJavaScript writes f the way JavaScript prints a number: without an
+// exponent between 1e-6 and 1e21, otherwise with an exponent of as few
+// digits as it takes, and infinities as Infinity and -Infinity
+func formatJavaScript(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if i := strings.IndexAny(s, "+-"); i > 0 {
+		// Go writes the exponent with at least two digits
+		if exponent := strings.TrimLeft(s[i+1:], "0"); exponent != "" {
+			s = s[:i+1] + exponent
 		}
-		stack = append(stack, tokenizer.TokenNumber)
-		return stack, true
-	} else {
-		fmt.Println(token.Value)
-		fmt.Println("Error: found invalid number")
-		return stack, false
 	}
+	return s
 }
 
-func handleEOF(stack []tokenizer.TokenType, processedCount int) bool {
-	if processedCount != 0 && len(stack) == 0 {
-		return true
-	} else {
-		fmt.Println("Error: Reached EOF prematurely.")
-		return false
+// unexpected reports that the current token is none of the expected ones
+func (p *parser) unexpected(expected ...tokenizer.TokenType) *ParseError {
+	names := make([]string, len(expected))
+	for i, tokenType := range expected {
+		names[i] = tokenType.String()
+	}
+	list := names[0]
+	if len(names) > 1 {
+		list = strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+	}
+
+	found := p.token.Type.String()
+	if p.token.Type == tokenizer.TokenString || p.token.Type == tokenizer.TokenNumber || p.token.Type == tokenizer.TokenIdentifier {
+		found += " " + p.token.Raw
+	}
+
+	kind := KindUnexpectedToken
+	if p.token.Type == tokenizer.TokenEOF {
+		kind = KindUnexpectedEOF
+	} else if p.token.Type == tokenizer.TokenRightBrace && p.token.Raw == "" {
+		// The end of a root object without braces is the end of the input
+		found, kind = tokenizer.TokenEOF.String(), KindUnexpectedEOF
 	}
+	err := newParseError(kind, p.token, fmt.Sprintf("expected %s but found %s", list, found))
+	err.Expected = expected
+	return err
 }
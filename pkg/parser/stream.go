@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"io"
+	"json-parser/pkg/tokenizer"
+)
+
+// StreamMode decides how the documents of a stream are separated
+type StreamMode int
+
+const (
+	StreamLines           StreamMode = iota // one document per line, as NDJSON and JSON Lines
+	StreamConcatenated                      // documents back to back, optionally separated by whitespace
+	StreamRecordSeparated                   // RFC 7464 JSON text sequences, each document after a record separator
+)
+
+// DocumentReader reads a stream of documents one at a time. With
+// StreamLines and StreamRecordSeparated a malformed document does not end
+// the stream: its error is returned and the next call continues with the
+// following line or record. Concatenated documents have no framing to
+// resynchronize on, so there the first error ends the stream.
+type DocumentReader struct {
+	p       parser
+	mode    StreamMode
+	pending bool               // p.token is the unread first token of the next document
+	start   tokenizer.Position // where the last document started
+	err     error              // error that ended the stream, returned from then on
+}
+
+func NewDocumentReader(t *tokenizer.Tokenizer, mode StreamMode) *DocumentReader {
+	return NewDocumentReaderWithOptions(t, mode, DefaultOptions())
+}
+
+// NewDocumentReaderWithOptions applies opts to every document, except
+// MaxBytes which caps the whole stream
+func NewDocumentReaderWithOptions(t *tokenizer.Tokenizer, mode StreamMode, opts Options) *DocumentReader {
+	opts = opts.configure(t)
+	switch mode {
+	case StreamLines:
+		t.SetFraming(tokenizer.FramingLines)
+	case StreamRecordSeparated:
+		t.SetFraming(tokenizer.FramingRecordSeparator)
+	default:
+		t.SetFraming(tokenizer.FramingNone)
+	}
+	return &DocumentReader{p: parser{t: t, h: NopHandler{}, opts: opts}, mode: mode}
+}
+
+// Next parses the next document, reporting it to h. Blank lines and empty
+// records are skipped. It returns io.EOF once the stream is exhausted. An
+// error reading the input, a stream over MaxBytes or cancellation ends the
+// stream, and every later call returns that error again.
+func (r *DocumentReader) Next(h Handler) error {
+	if r.err != nil {
+		return r.err
+	}
+	err := r.next(h)
+	if parseErr, ok := err.(*ParseError); ok {
+		r.recover(parseErr)
+	}
+	return err
+}
+
+// NextValue parses the next document into a document tree
+func (r *DocumentReader) NextValue() (*Value, error) {
+	b := &builder{duplicates: r.p.opts.DuplicateKeys}
+	if err := r.Next(b); err != nil {
+		return nil, err
+	}
+	return b.root, nil
+}
+
+// Start returns the position where the document last returned by Next
+// started, or where its error was found when it could not be read at all
+func (r *DocumentReader) Start() tokenizer.Position {
+	return r.start
+}
+
+func (r *DocumentReader) next(h Handler) error {
+	p := &r.p
+	p.h, p.depth, p.tokens = h, 0, 0
+	reset(h)
+
+	if err := r.read(); err != nil {
+		return err
+	}
+	switch r.mode {
+	case StreamLines:
+		for p.token.Type == tokenizer.TokenNewline {
+			if err := r.read(); err != nil {
+				return err
+			}
+		}
+	case StreamRecordSeparated:
+		if p.token.Type != tokenizer.TokenRecordSeparator && p.token.Type != tokenizer.TokenEOF {
+			return p.unexpected(tokenizer.TokenRecordSeparator)
+		}
+		for p.token.Type == tokenizer.TokenRecordSeparator {
+			if err := r.read(); err != nil {
+				return err
+			}
+		}
+	}
+	r.start = p.token.Start
+	if p.token.Type == tokenizer.TokenEOF {
+		r.pending = true
+		return io.EOF
+	}
+
+	if p.opts.RFC4627 && p.token.Type != tokenizer.TokenLeftBrace && p.token.Type != tokenizer.TokenLeftSquare {
+		return p.unexpected(tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare)
+	}
+	first := p.token
+	if err := p.parseValue(); err != nil {
+		return err
+	}
+
+	switch r.mode {
+	case StreamLines:
+		switch p.token.Type {
+		case tokenizer.TokenNewline:
+		case tokenizer.TokenEOF:
+			r.pending = true
+		default:
+			return p.unexpected(tokenizer.TokenNewline, tokenizer.TokenEOF)
+		}
+	case StreamRecordSeparated:
+		if p.token.Type != tokenizer.TokenRecordSeparator && p.token.Type != tokenizer.TokenEOF {
+			return p.unexpected(tokenizer.TokenRecordSeparator, tokenizer.TokenEOF)
+		}
+		r.pending = true
+		// RFC 7464 section 2.4: a number or literal running straight into
+		// the next record may have been cut short
+		if first.Type != tokenizer.TokenString && first.End == p.token.Start {
+			err := newParseError(KindTruncated, p.token, "top-level "+first.Type.String()+" not followed by whitespace may be truncated")
+			err.Pos = first.Start
+			return err
+		}
+	default:
+		r.pending = true
+	}
+	return nil
+}
+
+// read makes p.token the next token, unless it already holds an unread one
+func (r *DocumentReader) read() *ParseError {
+	if r.pending {
+		r.pending = false
+		return nil
+	}
+	if err := r.p.advance(); err != nil {
+		r.start = err.Pos
+		return err
+	}
+	return nil
+}
+
+// recover moves past the rest of the document err was found in, so the next
+// call starts with the following one. Errors reading the input and
+// cancellation end the stream.
+func (r *DocumentReader) recover(err *ParseError) {
+	if err.Kind == KindRead || err.Kind == KindDocumentTooLarge || err.Kind == KindCanceled || r.mode == StreamConcatenated {
+		r.err = err
+		return
+	}
+	switch err.Token.Type {
+	case tokenizer.TokenEOF, tokenizer.TokenRecordSeparator:
+		// The offending token is already the start of what comes next
+		r.pending = true
+	case tokenizer.TokenNewline:
+		// The offending token already ended the line
+	default:
+		if r.mode == StreamLines {
+			r.p.t.SkipTo('\n')
+		} else {
+			r.p.t.SkipTo(tokenizer.RecordSeparator)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"iter"
+	"math"
+	"strconv"
+)
+
+// ValueKind identifies the JSON type held by a Value
+type ValueKind int
+
+const (
+	NullValue ValueKind = iota
+	BoolValue
+	NumberValue
+	StringValue
+	ArrayValue
+	ObjectValue
+)
+
+var valueKindNames = map[ValueKind]string{
+	NullValue:   "null",
+	BoolValue:   "bool",
+	NumberValue: "number",
+	StringValue: "string",
+	ArrayValue:  "array",
+	ObjectValue: "object",
+}
+
+func (k ValueKind) String() string {
+	if name, ok := valueKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Member is a single key/value pair of an object
+type Member struct {
+	Key   string
+	Value *Value
+}
+
+// Value is a node of a parsed JSON document. Objects keep their members in
+// document order.
+type Value struct {
+	kind     ValueKind
+	boolean  bool
+	text     string // string contents or number literal
+	elements []*Value
+	members  []Member
+}
+
+func NewNull() *Value {
+	return &Value{kind: NullValue}
+}
+
+func NewBool(b bool) *Value {
+	return &Value{kind: BoolValue, boolean: b}
+}
+
+// NewNumber creates a number from its JSON literal, e.g. "-1.5e3"
+func NewNumber(literal string) *Value {
+	return &Value{kind: NumberValue, text: literal}
+}
+
+// NewFloat creates a number from f, written as the shortest literal that
+// reads back as f. Infinities and NaN become Infinity, -Infinity and NaN,
+// which only JSON5 and lenient JSON readers accept.
+func NewFloat(f float64) *Value {
+	switch {
+	case math.IsNaN(f):
+		return NewNumber("NaN")
+	case math.IsInf(f, 1):
+		return NewNumber("Infinity")
+	case math.IsInf(f, -1):
+		return NewNumber("-Infinity")
+	}
+	return NewNumber(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func NewString(s string) *Value {
+	return &Value{kind: StringValue, text: s}
+}
+
+func NewArray(elements ...*Value) *Value {
+	return &Value{kind: ArrayValue, elements: elements}
+}
+
+func NewObject(members ...Member) *Value {
+	return &Value{kind: ObjectValue, members: members}
+}
+
+func (v *Value) Kind() ValueKind {
+	return v.kind
+}
+
+func (v *Value) IsNull() bool {
+	return v.kind == NullValue
+}
+
+// Bool returns the value of a boolean, ok is false for any other kind
+func (v *Value) Bool() (b bool, ok bool) {
+	return v.boolean, v.kind == BoolValue
+}
+
+// Str returns the contents of a string, ok is false for any other kind
+func (v *Value) Str() (s string, ok bool) {
+	if v.kind != StringValue {
+		return "", false
+	}
+	return v.text, true
+}
+
+// Number returns a number as written in the document, ok is false for any
+// other kind
+func (v *Value) Number() (n Number, ok bool) {
+	if v.kind != NumberValue {
+		return "", false
+	}
+	return Number(v.text), true
+}
+
+// Float64 converts a number to a float64 like Number.Float64, ok is false
+// for any other kind or when the number does not convert exactly
+func (v *Value) Float64() (f float64, ok bool) {
+	if v.kind != NumberValue {
+		return 0, false
+	}
+	f, err := Number(v.text).Float64()
+	return f, err == nil
+}
+
+// Int64 converts a number to an int64 like Number.Int64, ok is false for any
+// other kind or when the number is not an integer that fits
+func (v *Value) Int64() (i int64, ok bool) {
+	if v.kind != NumberValue {
+		return 0, false
+	}
+	i, err := Number(v.text).Int64()
+	return i, err == nil
+}
+
+// Array returns the elements of an array, ok is false for any other kind
+func (v *Value) Array() (elements []*Value, ok bool) {
+	return v.elements, v.kind == ArrayValue
+}
+
+// Object returns the members of an object, ok is false for any other kind
+func (v *Value) Object() (members []Member, ok bool) {
+	return v.members, v.kind == ObjectValue
+}
+
+// Len returns the number of elements of an array or members of an object
+func (v *Value) Len() int {
+	switch v.kind {
+	case ArrayValue:
+		return len(v.elements)
+	case ObjectValue:
+		return len(v.members)
+	}
+	return 0
+}
+
+// Index returns the i-th element of an array, or nil when out of range
+func (v *Value) Index(i int) *Value {
+	if v.kind != ArrayValue || i < 0 || i >= len(v.elements) {
+		return nil
+	}
+	return v.elements[i]
+}
+
+// Get returns the value of the first member named key
+func (v *Value) Get(key string) (*Value, bool) {
+	if i := v.memberIndex(key); i >= 0 {
+		return v.members[i].Value, true
+	}
+	return nil, false
+}
+
+// GetAll returns the values of every member named key, in document order.
+// Objects only hold several when duplicate keys are kept.
+func (v *Value) GetAll(key string) []*Value {
+	var values []*Value
+	for _, member := range v.members {
+		if member.Key == key {
+			values = append(values, member.Value)
+		}
+	}
+	return values
+}
+
+func (v *Value) memberIndex(key string) int {
+	for i, member := range v.members {
+		if member.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Keys returns the member names of an object in document order
+func (v *Value) Keys() []string {
+	keys := make([]string, 0, len(v.members))
+	for _, member := range v.members {
+		keys = append(keys, member.Key)
+	}
+	return keys
+}
+
+// Elements iterates over the elements of an array
+func (v *Value) Elements() iter.Seq2[int, *Value] {
+	return func(yield func(int, *Value) bool) {
+		for i, element := range v.elements {
+			if !yield(i, element) {
+				return
+			}
+		}
+	}
+}
+
+// Members iterates over the members of an object in document order
+func (v *Value) Members() iter.Seq2[string, *Value] {
+	return func(yield func(string, *Value) bool) {
+		for _, member := range v.members {
+			if !yield(member.Key, member.Value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNeedMoreData is returned by NextToken of a feed tokenizer when the input
+// fed so far ends before the next token is complete
+var ErrNeedMoreData = errors.New("need more data")
+
+// ErrorKind classifies the errors returned by NextToken
+type ErrorKind int
+
+const (
+	ErrorUnexpectedCharacter ErrorKind = iota
+	ErrorUnterminatedString
+	ErrorInvalidEscape
+	ErrorLoneSurrogate
+	ErrorControlCharacter
+	ErrorInvalidUTF8
+	ErrorInvalidNumber
+	ErrorInvalidLiteral
+	ErrorRead
+	ErrorDocumentTooLarge
+	ErrorStringTooLong
+	ErrorNumberTooLong
+	ErrorCanceled // the context set by SetContext is done, see Err
+	ErrorUnterminatedComment
+)
+
+// Error is returned by the tokenizer for any malformed input
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Pos     Position
+	Err     error // underlying reader or context error, if any
+}
+
+func (e *Error) Error() string {
+	return e.Message + " at " + e.Pos.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (t *Tokenizer) errorAt(j int, kind ErrorKind, format string, args ...any) *Error {
+	if t.needMore {
+		// The error is dropped for ErrNeedMoreData, finding its position
+		// would cost as much as scanning the token
+		return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Pos: t.position}
+	}
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Pos: t.positionAt(j)}
+}
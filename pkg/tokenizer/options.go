@@ -0,0 +1,85 @@
+package tokenizer
+
+// SurrogatePolicy decides what happens to a \u escape holding half of a
+// UTF-16 surrogate pair without its other half
+type SurrogatePolicy int
+
+const (
+	SurrogateError   SurrogatePolicy = iota // reject the string
+	SurrogateReplace                        // decode it as U+FFFD
+)
+
+// UTF8Policy decides what happens to malformed UTF-8 inside strings
+type UTF8Policy int
+
+const (
+	UTF8Error   UTF8Policy = iota // reject the string
+	UTF8Replace                   // decode each bad sequence as U+FFFD
+)
+
+// Framing decides how the tokenizer reports the boundaries between documents
+// in a stream of them
+type Framing int
+
+const (
+	FramingNone            Framing = iota // line feeds are whitespace
+	FramingLines                          // line feeds are reported as TokenNewline
+	FramingRecordSeparator                // RecordSeparator is reported as TokenRecordSeparator
+)
+
+// RecordSeparator starts every document of an RFC 7464 JSON text sequence
+const RecordSeparator byte = 0x1E
+
+// Dialect selects the flavour of JSON the tokenizer accepts
+type Dialect int
+
+const (
+	DialectJSON  Dialect = iota // RFC 8259
+	DialectJSONC                // JSON with // and /* */ comments, as VS Code reads it
+	DialectJSON5                // JSON5, see https://spec.json5.org
+	DialectHjson                // Hjson, see https://hjson.github.io/syntax.html
+)
+
+var dialectNames = map[Dialect]string{
+	DialectJSON:  "json",
+	DialectJSONC: "jsonc",
+	DialectJSON5: "json5",
+	DialectHjson: "hjson",
+}
+
+func (d Dialect) String() string {
+	if name, ok := dialectNames[d]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseDialect returns the dialect with the given name
+func ParseDialect(name string) (Dialect, bool) {
+	for dialect, dialectName := range dialectNames {
+		if dialectName == name {
+			return dialect, true
+		}
+	}
+	return 0, false
+}
+
+// Options configures a Tokenizer. The zero value is strict RFC 8259 JSON.
+type Options struct {
+	LoneSurrogates SurrogatePolicy
+	InvalidUTF8    UTF8Policy
+	Dialect        Dialect
+	EmitComments   bool // report comments as TokenComment instead of skipping them
+	// LenientNumbers reads NaN, Infinity and -Infinity as numbers in JSON and
+	// JSONC, as Python's json module and many other tools write them
+	LenientNumbers bool
+}
+
+// Limits caps the size of the input and of single tokens, so untrusted input
+// cannot make the tokenizer buffer arbitrary amounts of data. Zero means no
+// limit.
+type Limits struct {
+	MaxBytes        int64 // size of the whole input
+	MaxStringLength int   // bytes between the quotes of a string
+	MaxNumberLength int   // characters of a number literal
+}
@@ -0,0 +1,15 @@
+package tokenizer
+
+import "fmt"
+
+// Position identifies a location in the input. Offset counts bytes from the
+// start of the input, Line and Column are 1-based and Column counts runes.
+type Position struct {
+	Offset int64
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d (offset %d)", p.Line, p.Column, p.Offset)
+}
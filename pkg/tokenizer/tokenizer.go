@@ -1,10 +1,15 @@
 package tokenizer
 
 import (
-	"bufio"
-	"fmt"
+	"bytes"
+	"context"
 	"io"
+	"math/big"
+	"strings"
 	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
 )
 
 type TokenType int
@@ -17,315 +22,1522 @@ const (
 	TokenColon
 	TokenComma
 	TokenString
-	TokenKeyValue
 	TokenNull
 	TokenTrue
 	TokenFalse
 	TokenNumber
-	TokenObject
-	TokenArray
 	TokenEOF
+	TokenNewline         // only with FramingLines
+	TokenRecordSeparator // only with FramingRecordSeparator
+	TokenComment         // only with Options.EmitComments
+	TokenIdentifier      // unquoted object key, only with DialectJSON5 and DialectHjson
 )
 
+var tokenNames = map[TokenType]string{
+	TokenLeftBrace:       "'{'",
+	TokenRightBrace:      "'}'",
+	TokenLeftSquare:      "'['",
+	TokenRightSquare:     "']'",
+	TokenColon:           "':'",
+	TokenComma:           "','",
+	TokenString:          "string",
+	TokenNull:            "null",
+	TokenTrue:            "true",
+	TokenFalse:           "false",
+	TokenNumber:          "number",
+	TokenEOF:             "end of input",
+	TokenNewline:         "end of line",
+	TokenRecordSeparator: "record separator",
+	TokenComment:         "comment",
+	TokenIdentifier:      "identifier",
+}
+
+func (tt TokenType) String() string {
+	if name, ok := tokenNames[tt]; ok {
+		return name
+	}
+	return "unknown"
+}
+
 type Token struct {
-	Type  TokenType
-	Value string // "" for no value
+	Type TokenType
+	// "" for no value, decoded contents for strings and identifiers, text
+	// between the delimiters for comments. Numbers of other dialects are
+	// written as JSON numbers, or Infinity, -Infinity and NaN.
+	Value string
+	Raw   string   // source text of the token, including quotes for strings
+	Start Position // position of the first byte of the token
+	End   Position // position just after the last byte of the token
 }
 
+const (
+	defaultBufferSize = 64 * 1024
+	maxEmptyReads     = 100
+	// cancelInterval is how many tokens are read between two checks for
+	// cancellation, on top of the check before every read
+	cancelInterval = 1024
+)
+
+// Tokenizer splits JSON input into tokens. Input is read in large chunks into
+// an internal buffer and every token is scanned in place, so a token is only
+// copied once when its value is produced.
 type Tokenizer struct {
-	scanner *bufio.Scanner
-	buffer  *byte
+	options  Options
+	limits   Limits
+	framing  Framing
+	inMemory bool // buf is the caller's input, token text may point into it
+	feeding  bool // input is handed over by Feed instead of read from r
+	closed   bool // Close was called, no more input will be fed
+	needMore bool // the current scan ran out of fed input
+	ctx      context.Context
+	done     <-chan struct{} // ctx.Done(), nil when it can never be cancelled
+	canceled error           // ctx.Err() once cancellation was noticed
+	tokens   int             // tokens read since the context was set
+	r        io.Reader       // nil once the input is exhausted
+	err      error           // read error, reported once the buffer runs dry
+	buf      []byte
+	pos      int      // index in buf of the next unread byte
+	mark     int      // index in buf of the byte kept by Mark
+	marked   bool     // Mark was called, buf keeps everything from mark on
+	position Position // position of buf[pos]
+	scratch  []byte   // reused to decode strings holding escapes
+	scan     progress // feed mode: how far the last unfinished scan got
+	nesting  []bool   // Hjson only: whether each open container is an object
+	colon    bool     // Hjson only: the last token was ':'
+	started  bool     // Hjson only: a token of the document was read
+	implicit bool     // Hjson only: the root object has no braces
+}
+
+// progress records how far a string, comment or run of digits was scanned
+// when the fed input ran out, so the next attempt continues from there
+// instead of scanning it again from its first byte, which would take
+// quadratic time for a long token fed in small chunks. Those scans start
+// with different bytes, so the offset tells whose progress it is.
+type progress struct {
+	saved   bool
+	offset  int64 // input offset of the first byte of the scan
+	j       int   // bytes from offset on already scanned
+	segment int   // strings only: start of the bytes not yet copied to scratch
+	decoded bool  // strings only: the value is assembled in scratch
 }
 
 func NewTokenizerFromReader(r io.Reader) *Tokenizer {
-	scanner := bufio.NewScanner(r)
-	scanner.Split(byteByByteSplitter)
+	return NewTokenizerFromReaderWithOptions(r, Options{})
+}
+
+func NewTokenizerFromReaderWithOptions(r io.Reader, options Options) *Tokenizer {
+	return &Tokenizer{
+		options:  options,
+		r:        r,
+		buf:      make([]byte, 0, defaultBufferSize),
+		position: Position{Line: 1, Column: 1},
+	}
+}
+
+// NewTokenizerFromBytes tokenizes input without copying it: the Value and Raw
+// of strings without escapes, and of numbers, refer directly to input. The
+// caller must not modify input while those tokens, or values built from
+// them, are in use.
+func NewTokenizerFromBytes(input []byte) *Tokenizer {
+	return NewTokenizerFromBytesWithOptions(input, Options{})
+}
+
+func NewTokenizerFromBytesWithOptions(input []byte, options Options) *Tokenizer {
+	return &Tokenizer{
+		options:  options,
+		inMemory: true,
+		buf:      input,
+		position: Position{Line: 1, Column: 1},
+	}
+}
+
+// NewFeedTokenizer tokenizes input handed to it in chunks by Feed, for
+// callers that cannot block in Read. NextToken returns ErrNeedMoreData
+// instead of a token that may continue in a later chunk, and reads nothing
+// in that case, so it can be called again after the next Feed.
+func NewFeedTokenizer() *Tokenizer {
+	return NewFeedTokenizerWithOptions(Options{})
+}
+
+func NewFeedTokenizerWithOptions(options Options) *Tokenizer {
 	return &Tokenizer{
-		scanner: scanner,
-		buffer:  nil,
+		options:  options,
+		feeding:  true,
+		buf:      make([]byte, 0, defaultBufferSize),
+		position: Position{Line: 1, Column: 1},
+	}
+}
+
+// Feed appends data to the input of a tokenizer made by NewFeedTokenizer.
+// The data is copied, so the caller may reuse it.
+func (t *Tokenizer) Feed(data []byte) {
+	if !t.feeding || t.closed {
+		panic("tokenizer: Feed without NewFeedTokenizer or after Close")
+	}
+	if len(t.buf)+len(data) > cap(t.buf) {
+		t.compact()
+	}
+	t.buf = append(t.buf, data...)
+}
+
+// Close marks the end of the input of a tokenizer made by NewFeedTokenizer.
+// From then on NextToken reports the end of input instead of
+// ErrNeedMoreData.
+func (t *Tokenizer) Close() {
+	t.closed = true
+}
+
+// Pos returns the position of the next byte to be read
+func (t *Tokenizer) Pos() Position {
+	return t.position
+}
+
+// Buffered returns the input already read from the underlying reader but not
+// consumed by NextToken yet, or after Mark everything since the mark.
+// Whatever follows a document is read through it first, e.g.
+// io.MultiReader(t.Buffered(), r). The returned reader is only valid until
+// the next call to NextToken.
+func (t *Tokenizer) Buffered() io.Reader {
+	if t.marked {
+		return bytes.NewReader(t.buf[t.mark:])
+	}
+	return bytes.NewReader(t.buf[t.pos:])
+}
+
+// Mark keeps the input from the next unread byte on, so Buffered still
+// returns it after tokens past it were read. Everything read from then on
+// stays buffered, so it is meant for looking at a token or two past the end
+// of a document.
+func (t *Tokenizer) Mark() {
+	t.mark, t.marked = t.pos, true
+}
+
+// compact moves the bytes still needed to the front of the buffer
+func (t *Tokenizer) compact() {
+	start := t.pos
+	if t.marked {
+		start = t.mark
+	}
+	if start == 0 {
+		return
+	}
+	n := copy(t.buf, t.buf[start:])
+	t.buf = t.buf[:n]
+	t.pos -= start
+	if t.marked {
+		t.mark -= start
+	}
+}
+
+// SetContext stops tokenizing once ctx is done. Cancellation is checked
+// before every read from the underlying reader and every 1024 tokens;
+// from then on NextToken returns an *Error of kind ErrorCanceled positioned
+// where tokenizing stopped. A Read that blocks is not interrupted.
+func (t *Tokenizer) SetContext(ctx context.Context) {
+	t.ctx, t.done, t.canceled, t.tokens = ctx, ctx.Done(), nil, 0
+}
+
+// checkCanceled records whether the context is done
+func (t *Tokenizer) checkCanceled() bool {
+	if t.canceled == nil && t.done != nil {
+		select {
+		case <-t.done:
+			t.canceled = t.ctx.Err()
+		default:
+		}
+	}
+	return t.canceled != nil
+}
+
+func (t *Tokenizer) canceledError() *Error {
+	return &Error{Kind: ErrorCanceled, Message: "tokenizing stopped: " + t.canceled.Error(), Pos: t.position, Err: t.canceled}
+}
+
+// Options returns the options the tokenizer was made with
+func (t *Tokenizer) Options() Options {
+	return t.options
+}
+
+// SetOptions replaces the options for the tokens read from now on
+func (t *Tokenizer) SetOptions(options Options) {
+	t.options = options
+}
+
+// SetLimits caps the size of the input and of single tokens read from now on
+func (t *Tokenizer) SetLimits(limits Limits) {
+	t.limits = limits
+}
+
+// SetFraming selects how document boundaries are reported from now on
+func (t *Tokenizer) SetFraming(framing Framing) {
+	t.framing = framing
+}
+
+// SkipTo discards input up to the next occurrence of delim, which is left
+// unread. A stream of documents uses it to resynchronize after a malformed
+// one. It returns false when the input ends first.
+func (t *Tokenizer) SkipTo(delim byte) bool {
+	t.nesting, t.colon, t.started, t.implicit = t.nesting[:0], false, false, false
+	t.scan.saved = false
+	for {
+		if i := bytes.IndexByte(t.buf[t.pos:], delim); i >= 0 {
+			t.consume(i)
+			return true
+		}
+		t.consume(len(t.buf) - t.pos)
+		if !t.fill() {
+			return false
+		}
+	}
+}
+
+// bufferedEnd returns the offset just past the last byte read into the buffer
+func (t *Tokenizer) bufferedEnd() int64 {
+	return t.position.Offset + int64(len(t.buf)-t.pos)
+}
+
+// overLimit reports whether the input has been found to exceed MaxBytes
+func (t *Tokenizer) overLimit() bool {
+	return t.limits.MaxBytes > 0 && t.bufferedEnd() > t.limits.MaxBytes
+}
+
+func (t *Tokenizer) tooLargeError() *Error {
+	return t.errorAt(0, ErrorDocumentTooLarge, "document exceeds the limit of %d bytes", t.limits.MaxBytes)
+}
+
+// fill reads more input into the buffer, keeping the unread bytes from t.pos
+// onwards. It returns false once no more input can be read.
+func (t *Tokenizer) fill() bool {
+	// Reading stops once the input is known to be too large, so the buffer
+	// never grows much past MaxBytes
+	if t.overLimit() || t.checkCanceled() {
+		return false
+	}
+	if t.feeding {
+		t.needMore = !t.closed
+		return false
+	}
+	if t.r == nil {
+		return false
+	}
+
+	// Slide the unread bytes to the front, growing the buffer when a single
+	// token does not fit
+	t.compact()
+	if len(t.buf) == cap(t.buf) {
+		grown := make([]byte, len(t.buf), 2*cap(t.buf))
+		copy(grown, t.buf)
+		t.buf = grown
+	}
+
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := t.r.Read(t.buf[len(t.buf):cap(t.buf)])
+		t.buf = t.buf[:len(t.buf)+n]
+		if err != nil {
+			t.r = nil
+			if err != io.EOF {
+				t.err = err
+			}
+			return n > 0
+		}
+		if n > 0 {
+			return true
+		}
+	}
+	t.r = nil
+	t.err = io.ErrNoProgress
+	return false
+}
+
+// text returns buf[t.pos+i:t.pos+j] as a string. In memory input is never
+// overwritten, so the string shares its bytes instead of copying them.
+func (t *Tokenizer) text(i, j int) string {
+	if !t.inMemory || i == j {
+		return string(t.buf[t.pos+i : t.pos+j])
+	}
+	return unsafe.String(&t.buf[t.pos+i], j-i)
+}
+
+// byteAt returns the byte j bytes past the next unread one, reading more input
+// when needed
+func (t *Tokenizer) byteAt(j int) (byte, bool) {
+	for t.pos+j >= len(t.buf) {
+		if !t.fill() {
+			return 0, false
+		}
 	}
+	return t.buf[t.pos+j], true
 }
 
-func byteByByteSplitter(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if atEOF && len(data) == 0 {
-		return 0, nil, nil
+// resume returns the progress saved for a scan starting j bytes past the
+// next unread one, if there is any, and forgets it
+func (t *Tokenizer) resume(j int) (progress, bool) {
+	if !t.scan.saved || t.scan.offset != t.position.Offset+int64(j) {
+		return progress{}, false
 	}
+	t.scan.saved = false
+	return t.scan, true
+}
 
-	if len(data) > 0 {
-		return 1, data[:1], nil
+// suspend saves p for a scan starting j bytes past the next unread one when
+// it ran out of fed input
+func (t *Tokenizer) suspend(j int, p progress) {
+	if t.needMore {
+		p.saved, p.offset = true, t.position.Offset+int64(j)
+		t.scan = p
 	}
+}
+
+// consume marks the next n bytes as read and moves the position past them
+func (t *Tokenizer) consume(n int) {
+	t.position = advance(t.position, t.buf[t.pos:t.pos+n])
+	t.pos += n
+}
 
-	return 0, nil, nil
+// positionAt returns the position of the byte j bytes past the next unread one
+func (t *Tokenizer) positionAt(j int) Position {
+	return advance(t.position, t.buf[t.pos:t.pos+j])
 }
 
+func advance(p Position, data []byte) Position {
+	for _, char := range data {
+		if char == '\n' {
+			p.Line++
+			p.Column = 1
+		} else if char&0xC0 != 0x80 {
+			// Continuation bytes of a multi-byte rune share the rune's column
+			p.Column++
+		}
+	}
+	p.Offset += int64(len(data))
+	return p
+}
+
+// NextToken reads the next token. Comments are skipped like whitespace
+// unless Options.EmitComments is set.
 func (t *Tokenizer) NextToken() (Token, error) {
-	var char byte
+	for {
+		token, err := t.nextToken()
+		if err != nil || token.Type != TokenComment || t.options.EmitComments {
+			return token, err
+		}
+	}
+}
+
+func (t *Tokenizer) nextToken() (Token, error) {
+	if t.done != nil {
+		if t.tokens%cancelInterval == 0 {
+			t.checkCanceled()
+		}
+		t.tokens++
+	}
+	if t.canceled != nil {
+		return Token{}, t.canceledError()
+	}
+
+	t.needMore = false
+	t.skipWhitespace()
+
+	char, ok := t.byteAt(0)
+	if !ok {
+		if t.canceled != nil {
+			return Token{}, t.canceledError()
+		}
+		if t.needMore {
+			return Token{}, ErrNeedMoreData
+		}
+		if t.overLimit() {
+			return Token{}, t.tooLargeError()
+		}
+		if t.err != nil {
+			return Token{}, &Error{Kind: ErrorRead, Message: t.err.Error(), Pos: t.position, Err: t.err}
+		}
+		if t.implicit && len(t.nesting) == 1 {
+			// The end of the input closes a root object without braces
+			t.implicit = false
+			t.track(TokenRightBrace)
+			return Token{Type: TokenRightBrace, Value: "}", Start: t.position, End: t.position}, nil
+		}
+		return Token{Type: TokenEOF, Start: t.position, End: t.position}, nil
+	}
+
+	if t.options.Dialect == DialectHjson && !t.started && char != '{' && char != '[' && char != '#' && !(char == '/' && t.commentAt(0)) {
+		// A root object may leave out its braces, which are then reported as
+		// tokens with an empty Raw
+		root := t.rootObjectAt()
+		if t.needMore {
+			return Token{}, ErrNeedMoreData
+		}
+		if root {
+			t.implicit = true
+			t.track(TokenLeftBrace)
+			return Token{Type: TokenLeftBrace, Value: "{", Start: t.position, End: t.position}, nil
+		}
+	}
+
+	token := Token{Start: t.position}
+	n, err := t.dispatch(char, &token)
+	if t.canceled != nil {
+		// Reading stopped in the middle of the token
+		return Token{}, t.canceledError()
+	}
+	if t.needMore {
+		// Whatever was scanned may continue in the next chunk
+		return Token{}, ErrNeedMoreData
+	}
+	if err != nil {
+		// The token may only look malformed because reading was cut short
+		if t.overLimit() {
+			return Token{}, t.tooLargeError()
+		}
+		return Token{}, err
+	}
+	if t.limits.MaxBytes > 0 && token.Start.Offset+int64(n) > t.limits.MaxBytes {
+		return Token{}, t.tooLargeError()
+	}
+	if t.implicit && token.Type == TokenRightBrace && len(t.nesting) == 1 {
+		return Token{}, t.errorAt(0, ErrorUnexpectedCharacter, "unexpected character: }, the root object has no opening brace")
+	}
+	t.consume(n)
+	token.End = t.position
+	if t.options.Dialect == DialectHjson && token.Type != TokenComment {
+		t.track(token.Type)
+	}
+	return token, nil
+}
 
-	if t.buffer != nil {
-		char = *t.buffer
-		t.buffer = nil
+// track follows the nesting of an Hjson document, which tells whether an
+// unquoted word is a key or a value
+func (t *Tokenizer) track(tokenType TokenType) {
+	switch tokenType {
+	case TokenLeftBrace:
+		t.nesting = append(t.nesting, true)
+	case TokenLeftSquare:
+		t.nesting = append(t.nesting, false)
+	case TokenRightBrace, TokenRightSquare:
+		if len(t.nesting) > 0 {
+			t.nesting = t.nesting[:len(t.nesting)-1]
+		}
+	}
+	t.colon = tokenType == TokenColon
+	t.started = true
+}
+
+// rootObjectAt reports whether the next Hjson token is a key followed by a
+// colon, which starts a root object written without braces
+func (t *Tokenizer) rootObjectAt() bool {
+	j := 0
+	char, ok := t.byteAt(0)
+	if char == '"' || char == '\'' {
+		quote := char
+		for j = 1; ; j++ {
+			char, ok = t.byteAt(j)
+			if !ok || char == '\n' || char == '\r' {
+				return false
+			}
+			if char == '\\' {
+				j++
+			} else if char == quote {
+				break
+			}
+		}
+		j++
 	} else {
-		if !t.scanner.Scan() {
-			if err := t.scanner.Err(); err != nil {
-				return Token{}, err
+		for ok && char > ' ' && !isPunctuator(char) {
+			j++
+			char, ok = t.byteAt(j)
+		}
+		if j == 0 {
+			return false
+		}
+	}
+	for {
+		char, ok = t.byteAt(j)
+		if !ok || !isWhitespace(char) {
+			return ok && char == ':'
+		}
+		j++
+	}
+}
+
+// atKey reports whether an Hjson object key comes next
+func (t *Tokenizer) atKey() bool {
+	return !t.colon && len(t.nesting) > 0 && t.nesting[len(t.nesting)-1]
+}
+
+func (t *Tokenizer) skipWhitespace() {
+	for {
+		j := 0
+		for t.pos+j < len(t.buf) {
+			char := t.buf[t.pos+j]
+			if isWhitespace(char) && (char != '\n' || t.framing != FramingLines) {
+				j++
+				continue
+			}
+			n := 0
+			if t.options.Dialect == DialectJSON5 {
+				n = t.json5WhitespaceAt(j)
+			}
+			if n == 0 {
+				break
 			}
-			return Token{Type: TokenEOF}, nil
+			j += n
+		}
+		t.consume(j)
+		if t.pos < len(t.buf) || !t.fill() {
+			return
 		}
-		char = t.scanner.Bytes()[0]
 	}
+}
 
+// json5WhitespaceAt returns the length of the whitespace character JSON5
+// adds to JSON's starting j bytes past the next unread one, or 0
+func (t *Tokenizer) json5WhitespaceAt(j int) int {
+	switch char := t.buf[t.pos+j]; {
+	case char == '\v' || char == '\f':
+		return 1
+	case char >= utf8.RuneSelf:
+		r, n := t.decodeRuneAt(j)
+		if r == '\uFEFF' || r == '\u2028' || r == '\u2029' || unicode.Is(unicode.Zs, r) {
+			return n
+		}
+	}
+	return 0
+}
+
+// dispatch scans the token starting with char into token without consuming
+// it, and returns the token's length in bytes
+func (t *Tokenizer) dispatch(char byte, token *Token) (int, error) {
 	switch char {
 	case '{':
-		return Token{Type: TokenLeftBrace, Value: "{"}, nil
+		token.Type, token.Value, token.Raw = TokenLeftBrace, "{", "{"
+		return 1, nil
 	case '}':
-		return Token{Type: TokenRightBrace, Value: "}"}, nil
+		token.Type, token.Value, token.Raw = TokenRightBrace, "}", "}"
+		return 1, nil
 	case '[':
-		return Token{Type: TokenLeftSquare, Value: "["}, nil
+		token.Type, token.Value, token.Raw = TokenLeftSquare, "[", "["
+		return 1, nil
 	case ']':
-		return Token{Type: TokenRightSquare, Value: "]"}, nil
+		token.Type, token.Value, token.Raw = TokenRightSquare, "]", "]"
+		return 1, nil
 	case ':':
-		return Token{Type: TokenColon, Value: ":"}, nil
+		token.Type, token.Value, token.Raw = TokenColon, ":", ":"
+		return 1, nil
 	case ',':
-		return Token{Type: TokenComma, Value: ","}, nil
+		token.Type, token.Value, token.Raw = TokenComma, ",", ","
+		return 1, nil
+	case '\n':
+		// Only reached with FramingLines, otherwise it is skipped as whitespace
+		token.Type, token.Value, token.Raw = TokenNewline, "\n", "\n"
+		return 1, nil
+	case RecordSeparator:
+		if t.framing == FramingRecordSeparator {
+			token.Type, token.Value, token.Raw = TokenRecordSeparator, "\x1e", "\x1e"
+			return 1, nil
+		}
 	case '"':
-		return t.ReadString()
-	case 'n':
-		return t.ReadNull("n")
-	case 't':
-		return t.ReadTrue("t")
-	case 'f':
-		return t.ReadFalse("f")
+		return t.readString(token)
+	case '/':
+		if t.options.Dialect != DialectJSON && t.options.Dialect != DialectHjson {
+			return t.readComment(token)
+		}
 	}
 
-	if char == ' ' || char == '\t' || char == '\n' || char == '\r' {
-		return t.NextToken()
+	if t.options.Dialect == DialectHjson {
+		return t.dispatchHjson(char, token)
+	}
+	if t.options.Dialect == DialectJSON5 {
+		switch {
+		case char == '\'':
+			return t.readString(token)
+		case isDigit(char) || char == '-' || char == '+' || char == '.':
+			return t.readJSON5Number(token)
+		case char == '$' || char == '_' || char == '\\' || char >= utf8.RuneSelf || isLetter(char):
+			return t.readIdentifier(token)
+		}
+	} else {
+		switch {
+		case char == 'n':
+			return t.readLiteral(token, TokenNull, "null")
+		case char == 't':
+			return t.readLiteral(token, TokenTrue, "true")
+		case char == 'f':
+			return t.readLiteral(token, TokenFalse, "false")
+		case (char == 'I' || char == 'N') && t.options.LenientNumbers:
+			return t.readNonFinite(token, 0, "")
+		case isDigit(char) || char == '-':
+			return t.readNumber(token)
+		}
+	}
+
+	return 0, t.errorAt(0, ErrorUnexpectedCharacter, "unexpected character: %c", t.runeAt(0))
+}
+
+// readComment scans a // comment, or in Hjson a # comment, which ends before
+// the line break, or a /* */ comment
+func (t *Tokenizer) readComment(token *Token) (int, error) {
+	first, _ := t.byteAt(0)
+	char, _ := t.byteAt(1)
+	switch {
+	case first == '#' || char == '/':
+		prefix := 2
+		if first == '#' {
+			prefix = 1
+		}
+		j := prefix
+		if p, ok := t.resume(0); ok {
+			j = p.j
+		}
+		for {
+			for t.pos+j < len(t.buf) && t.buf[t.pos+j] != '\n' && t.buf[t.pos+j] != '\r' {
+				j++
+			}
+			if t.pos+j < len(t.buf) || !t.fill() {
+				break
+			}
+		}
+		if t.needMore {
+			// The comment may go on in the next chunk
+			t.suspend(0, progress{j: j})
+			return 0, ErrNeedMoreData
+		}
+		raw := t.text(0, j)
+		token.Type, token.Value, token.Raw = TokenComment, raw[prefix:], raw
+		return j, nil
+	case char == '*':
+		j := 2
+		if p, ok := t.resume(0); ok {
+			j = p.j
+		}
+		for ; ; j++ {
+			char, ok := t.byteAt(j)
+			if !ok {
+				t.suspend(0, progress{j: j})
+				return 0, t.errorAt(0, ErrorUnterminatedComment, "unterminated comment")
+			}
+			if char == '*' {
+				next, _ := t.byteAt(j + 1)
+				if t.needMore {
+					// The '/' may come with the next chunk
+					t.suspend(0, progress{j: j})
+					return 0, ErrNeedMoreData
+				}
+				if next == '/' {
+					raw := t.text(0, j+2)
+					token.Type, token.Value, token.Raw = TokenComment, raw[2:j], raw
+					return j + 2, nil
+				}
+			}
+		}
 	}
+	return 0, t.errorAt(0, ErrorUnexpectedCharacter, "unexpected character: /, a comment starts with // or /*")
+}
 
-	if unicode.IsDigit(rune(char)) || char == '-' {
-		return t.ReadNumber(char)
+// decodeRuneAt decodes the rune starting j bytes past the next unread one,
+// reading more input when it is cut off, and returns it with its length
+func (t *Tokenizer) decodeRuneAt(j int) (rune, int) {
+	for !utf8.FullRune(t.buf[t.pos+j:]) && t.fill() {
 	}
+	return utf8.DecodeRune(t.buf[t.pos+j:])
+}
 
-	return Token{}, fmt.Errorf("unexpected character: %c", char)
+// runeAt decodes the rune starting j bytes past the next unread one, for use
+// in error messages
+func (t *Tokenizer) runeAt(j int) rune {
+	// Running out of fed input here must not turn the error into
+	// ErrNeedMoreData
+	needMore := t.needMore
+	t.byteAt(j + utf8.UTFMax - 1)
+	t.needMore = needMore
+	r, _ := utf8.DecodeRune(t.buf[t.pos+j:])
+	return r
 }
 
-func (t *Tokenizer) ReadString() (Token, error) {
-	var str string
-	for t.scanner.Scan() {
-		char := t.scanner.Bytes()[0]
-		if char == '"' {
-			return Token{Type: TokenString, Value: str}, nil
+// readString scans a string enclosed in double quotes, or in JSON5 single
+// quotes
+func (t *Tokenizer) readString(token *Token) (int, error) {
+	// The value is sliced straight out of the raw text unless an escape or a
+	// replaced sequence forces it to be assembled in t.scratch
+	decoded := false
+	segment := 1 // start of the bytes not yet copied to t.scratch
+	quote := t.buf[t.pos]
+
+	j := 1
+	if p, ok := t.resume(0); ok {
+		// t.scratch still holds what was decoded before the input ran out
+		j, segment, decoded = p.j, p.segment, p.decoded
+	} else {
+		t.scratch = t.scratch[:0]
+	}
+	for {
+		// Skip over a run of bytes that need no decoding
+		for t.pos+j < len(t.buf) {
+			char := t.buf[t.pos+j]
+			if char == quote || char == '\\' || char < 0x20 || char >= utf8.RuneSelf {
+				break
+			}
+			j++
 		}
-		if char == '\\' {
-			valid, sequence := t.ValidateEscapeString()
-			if !valid {
-				return Token{}, fmt.Errorf("invalid escape string")
+		if max := t.limits.MaxStringLength; max > 0 && j-1 > max {
+			return 0, t.errorAt(0, ErrorStringTooLong, "string exceeds the limit of %d bytes", max)
+		}
+		if t.pos+j == len(t.buf) {
+			if !t.fill() {
+				t.suspend(0, progress{j: j, segment: segment, decoded: decoded})
+				return 0, t.errorAt(j, ErrorUnterminatedString, "unterminated string")
+			}
+			continue
+		}
+
+		char := t.buf[t.pos+j]
+		switch {
+		case char == quote:
+			raw := t.text(0, j+1)
+			value := raw[1:j]
+			if decoded {
+				t.scratch = append(t.scratch, t.buf[t.pos+segment:t.pos+j]...)
+				value = string(t.scratch)
+			}
+			token.Type, token.Value, token.Raw = TokenString, value, raw
+			return j + 1, nil
+
+		case char == '\\':
+			r, n, err := t.readEscape(j)
+			if t.needMore {
+				// Even a valid escape may have been read from a cut off
+				// surrogate pair, so it is decoded again once more is fed
+				t.suspend(0, progress{j: j, segment: segment, decoded: decoded})
+				return 0, ErrNeedMoreData
+			}
+			if err != nil {
+				return 0, err
+			}
+			decoded = true
+			t.scratch = append(t.scratch, t.buf[t.pos+segment:t.pos+j]...)
+			if r != noRune {
+				t.scratch = utf8.AppendRune(t.scratch, r)
 			}
-			str += string(char) + sequence
-		} else {
-			if char < 0x20 {
-				return Token{}, fmt.Errorf("invalid character in string: control character 0x%02X", char)
+			j += n
+			segment = j
+
+		case char < 0x20 && (t.options.Dialect != DialectJSON5 || char == '\n' || char == '\r'):
+			return 0, t.errorAt(j, ErrorControlCharacter, "invalid character in string: control character 0x%02X", char)
+
+		case char < 0x20:
+			// JSON5 only requires line breaks to be escaped
+			j++
+
+		default:
+			n, valid := t.utf8SequenceAt(j)
+			if t.needMore {
+				t.suspend(0, progress{j: j, segment: segment, decoded: decoded})
+				return 0, ErrNeedMoreData
+			}
+			if !valid {
+				if t.options.InvalidUTF8 != UTF8Replace {
+					return 0, t.errorAt(j, ErrorInvalidUTF8, "invalid UTF-8 sequence % X in string", t.buf[t.pos+j:t.pos+j+n])
+				}
+				decoded = true
+				t.scratch = append(t.scratch, t.buf[t.pos+segment:t.pos+j]...)
+				t.scratch = utf8.AppendRune(t.scratch, utf8.RuneError)
+				segment = j + n
 			}
-			str += string(char)
+			j += n
 		}
 	}
-	return Token{}, fmt.Errorf("unterminated string")
 }
 
-func (t *Tokenizer) ValidateEscapeString() (bool, string) {
-	if !t.scanner.Scan() {
-		return false, ""
+// utf8SequenceAt checks the multi-byte UTF-8 sequence starting j bytes past
+// the next unread one. It returns the number of bytes making up the sequence
+// and whether they form a valid encoding, which rules out overlong forms,
+// surrogates and code points above U+10FFFF.
+func (t *Tokenizer) utf8SequenceAt(j int) (int, bool) {
+	size := 0
+	switch lead := t.buf[t.pos+j]; {
+	case lead >= 0xC2 && lead <= 0xDF:
+		size = 2
+	case lead >= 0xE0 && lead <= 0xEF:
+		size = 3
+	case lead >= 0xF0 && lead <= 0xF4:
+		size = 4
+	default:
+		return 1, false
+	}
+
+	for n := 1; n < size; n++ {
+		// A byte that does not continue the sequence is left for the caller,
+		// it may be the closing quote
+		if char, ok := t.byteAt(j + n); !ok || char&0xC0 != 0x80 {
+			return n, false
+		}
 	}
 
-	char := t.scanner.Bytes()[0]
+	_, n := utf8.DecodeRune(t.buf[t.pos+j : t.pos+j+size])
+	return size, n == size
+}
+
+// readEscape decodes the escape sequence starting with the backslash j bytes
+// past the next unread one. A \u escape holding a high surrogate is combined
+// with an immediately following low surrogate escape.
+func (t *Tokenizer) readEscape(j int) (rune, int, error) {
+	char, ok := t.byteAt(j + 1)
+	if !ok {
+		return 0, 0, t.errorAt(j, ErrorInvalidEscape, "invalid escape string")
+	}
 
 	switch char {
-	case 'b', 'f', 'n', 'r', 't', '"', '\\', '/':
-		return true, string(char)
+	case '"', '\\', '/':
+		return rune(char), 2, nil
+	case 'b':
+		return '\b', 2, nil
+	case 'f':
+		return '\f', 2, nil
+	case 'n':
+		return '\n', 2, nil
+	case 'r':
+		return '\r', 2, nil
+	case 't':
+		return '\t', 2, nil
 	case 'u':
-		unicode := string(char)
-		for i := 0; i < 4; i++ {
-			if !t.scanner.Scan() {
-				return false, ""
-			}
-			digit := t.scanner.Bytes()[0]
-			if !isHexDigit(digit) {
-				return false, ""
+		r, ok := t.hexAt(j + 2)
+		if !ok {
+			return 0, 0, t.errorAt(j, ErrorInvalidEscape, "invalid escape string")
+		}
+		if !utf16.IsSurrogate(r) {
+			return r, 6, nil
+		}
+		if r < 0xDC00 {
+			backslash, _ := t.byteAt(j + 6)
+			u, _ := t.byteAt(j + 7)
+			if low, ok := t.hexAt(j + 8); backslash == '\\' && u == 'u' && ok && low >= 0xDC00 && low <= 0xDFFF {
+				return utf16.DecodeRune(r, low), 12, nil
 			}
-			unicode += string(digit)
 		}
-		return true, unicode
+		if t.options.LoneSurrogates == SurrogateReplace {
+			return utf8.RuneError, 6, nil
+		}
+		return 0, 0, t.errorAt(j, ErrorLoneSurrogate, "unpaired surrogate in \\u escape")
 	default:
-		return false, ""
+		if t.options.Dialect == DialectJSON5 {
+			return t.readJSON5Escape(j, char)
+		}
+		if t.options.Dialect == DialectHjson && char == '\'' {
+			return '\'', 2, nil
+		}
+		return 0, 0, t.errorAt(j, ErrorInvalidEscape, "invalid escape string")
 	}
 }
 
-func isHexDigit(b byte) bool {
-	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+// noRune is decoded from a JSON5 line continuation, which stands for nothing
+const noRune rune = -1
+
+// readJSON5Escape decodes the escapes JSON5 adds to those of JSON: \v, \0,
+// \xHH, a backslash before a line break, which continues the string on the
+// next line, and a backslash before any other character, which stands for
+// that character
+func (t *Tokenizer) readJSON5Escape(j int, char byte) (rune, int, error) {
+	switch {
+	case char == 'v':
+		return '\v', 2, nil
+	case char == '0':
+		// \0 is not the start of a JavaScript octal escape
+		if next, ok := t.byteAt(j + 2); !ok || !isDigit(next) {
+			return 0, 2, nil
+		}
+	case char == 'x':
+		high, _ := t.byteAt(j + 2)
+		low, _ := t.byteAt(j + 3)
+		if isHexDigit(high) && isHexDigit(low) {
+			return hexValue(high)<<4 | hexValue(low), 4, nil
+		}
+	case char == '\n':
+		return noRune, 2, nil
+	case char == '\r':
+		if next, _ := t.byteAt(j + 2); next == '\n' {
+			return noRune, 3, nil
+		}
+		return noRune, 2, nil
+	case isDigit(char):
+	default:
+		r, n := t.decodeRuneAt(j + 1)
+		if r == '\u2028' || r == '\u2029' {
+			return noRune, 1 + n, nil
+		}
+		if r != utf8.RuneError || n > 1 {
+			return r, 1 + n, nil
+		}
+	}
+	return 0, 0, t.errorAt(j, ErrorInvalidEscape, "invalid escape string")
 }
 
-func (t *Tokenizer) ReadNull(str string) (Token, error) {
-	expected := "null"
-	for t.scanner.Scan() {
-		char := t.scanner.Bytes()[0]
-		str += string(char)
-		if len(str) > len(expected) || char != expected[len(str)-1] {
-			t.buffer = &char
-			str = str[:len(str)-1]
-			break
+// hexAt decodes the four hex digits starting j bytes past the next unread one
+func (t *Tokenizer) hexAt(j int) (rune, bool) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		digit, ok := t.byteAt(j + i)
+		if !ok || !isHexDigit(digit) {
+			return 0, false
 		}
-		if len(str) == len(expected) {
-			break
+		r = r<<4 | hexValue(digit)
+	}
+	return r, true
+}
+
+func (t *Tokenizer) readLiteral(token *Token, tokenType TokenType, literal string) (int, error) {
+	for i := 1; i < len(literal); i++ {
+		if char, ok := t.byteAt(i); !ok || char != literal[i] {
+			return 0, t.errorAt(i, ErrorInvalidLiteral, "incorrect spelling for '%s'", literal)
+		}
+	}
+	token.Type, token.Value, token.Raw = tokenType, literal, literal
+	return len(literal), nil
+}
+
+func (t *Tokenizer) readNumber(token *Token) (int, error) {
+	j := 0
+	if char, _ := t.byteAt(0); char == '-' {
+		j++
+	}
+
+	// Integer part
+	char, ok := t.byteAt(j)
+	switch {
+	case ok && char == 'I' && j == 1 && t.options.LenientNumbers:
+		return t.readNonFinite(token, j, "-")
+	case !ok || !isDigit(char):
+		return 0, t.errorAt(j, ErrorInvalidNumber, "expected digit in number")
+	case char == '0':
+		j++
+		if char, ok := t.byteAt(j); ok && isDigit(char) {
+			return 0, t.errorAt(j-1, ErrorInvalidNumber, "invalid leading 0 found")
+		}
+	default:
+		j = t.digitsAt(j)
+	}
+
+	// Fraction part
+	if char, ok := t.byteAt(j); ok && char == '.' {
+		j++
+		if char, ok := t.byteAt(j); !ok || !isDigit(char) {
+			return 0, t.errorAt(j, ErrorInvalidNumber, "expected digit after decimal point")
+		}
+		j = t.digitsAt(j)
+	}
+
+	// Exponent part
+	if char, ok := t.byteAt(j); ok && (char == 'e' || char == 'E') {
+		j++
+		char, ok := t.byteAt(j)
+		if ok && (char == '+' || char == '-') {
+			j++
+			if char, ok := t.byteAt(j); !ok || !isDigit(char) {
+				return 0, t.errorAt(j, ErrorInvalidNumber, "invalid sign with no numbers")
+			}
+		} else if !ok || !isDigit(char) {
+			return 0, t.errorAt(j, ErrorInvalidNumber, "invalid exponential number")
 		}
+		j = t.digitsAt(j)
 	}
 
-	if str == "null" {
-		return Token{Type: TokenNull, Value: "null"}, nil
+	if max := t.limits.MaxNumberLength; max > 0 && j > max {
+		return 0, t.errorAt(0, ErrorNumberTooLong, "number exceeds the limit of %d characters", max)
+	}
+	if t.needMore {
+		// The number may go on in the next chunk
+		return 0, ErrNeedMoreData
 	}
-	return Token{}, fmt.Errorf("incorrect spelling for 'null'")
+
+	raw := t.text(0, j)
+	token.Type, token.Value, token.Raw = TokenNumber, raw, raw
+	return j, nil
 }
 
-func (t *Tokenizer) ReadTrue(str string) (Token, error) {
-	expected := "true"
-	for t.scanner.Scan() {
-		char := t.scanner.Bytes()[0]
-		str += string(char)
-		if len(str) > len(expected) || char != expected[len(str)-1] {
-			t.buffer = &char
-			str = str[:len(str)-1]
-			break
+// dispatchHjson scans the Hjson tokens that are not JSON punctuation. A word
+// without quotes is a key where a key comes next, and otherwise a quoteless
+// string, or the number, true, false or null it spells.
+func (t *Tokenizer) dispatchHjson(char byte, token *Token) (int, error) {
+	switch {
+	case char == '#' || char == '/' && t.commentAt(0):
+		return t.readComment(token)
+	case t.atKey() && char == '\'':
+		return t.readString(token)
+	case t.atKey():
+		return t.readHjsonKey(token)
+	case char == '\'':
+		second, _ := t.byteAt(1)
+		third, _ := t.byteAt(2)
+		if second == '\'' && third == '\'' {
+			return t.readMultiline(token)
 		}
-		if len(str) == len(expected) {
+		return t.readString(token)
+	}
+	return t.readQuoteless(token)
+}
+
+// readHjsonKey scans an unquoted Hjson key, which ends at whitespace or any
+// of the characters {}[],:
+func (t *Tokenizer) readHjsonKey(token *Token) (int, error) {
+	j := 0
+	for {
+		char, ok := t.byteAt(j)
+		if !ok || char <= ' ' || isPunctuator(char) {
 			break
 		}
+		j++
+		if max := t.limits.MaxStringLength; max > 0 && j > max {
+			return 0, t.errorAt(0, ErrorStringTooLong, "key exceeds the limit of %d bytes", max)
+		}
+	}
+	if j == 0 {
+		return 0, t.errorAt(0, ErrorUnexpectedCharacter, "unexpected character: %c", t.runeAt(0))
 	}
 
-	if str == "true" {
-		return Token{Type: TokenTrue, Value: "true"}, nil
+	raw := t.text(0, j)
+	value, err := t.validUTF8(raw)
+	if err != nil {
+		return 0, err
 	}
-	return Token{}, fmt.Errorf("incorrect spelling for 'true'")
+	token.Type, token.Value, token.Raw = TokenIdentifier, value, raw
+	return j, nil
 }
 
-func (t *Tokenizer) ReadFalse(str string) (Token, error) {
-	expected := "false"
-	for t.scanner.Scan() {
-		char := t.scanner.Bytes()[0]
-		str += string(char)
-		if len(str) > len(expected) || char != expected[len(str)-1] {
-			t.buffer = &char
-			str = str[:len(str)-1]
-			break
+// readQuoteless scans an Hjson value without quotes. It runs to the end of
+// the line, unless what comes before a comma, a closing bracket or a comment
+// is a number, true, false or null. Trailing whitespace is not part of it.
+func (t *Tokenizer) readQuoteless(token *Token) (int, error) {
+	j := 0
+	for {
+		char, ok := t.byteAt(j)
+		eol := !ok || char == '\n' || char == '\r'
+		if eol || char == ',' || char == '}' || char == ']' || char == '#' || char == '/' && t.commentAt(j) {
+			end := j
+			for end > 0 && (t.buf[t.pos+end-1] == ' ' || t.buf[t.pos+end-1] == '\t') {
+				end--
+			}
+			raw := t.text(0, end)
+			value := raw
+			switch {
+			case raw == "true":
+				token.Type = TokenTrue
+			case raw == "false":
+				token.Type = TokenFalse
+			case raw == "null":
+				token.Type = TokenNull
+			case isNumber(raw):
+				if max := t.limits.MaxNumberLength; max > 0 && end > max {
+					return 0, t.errorAt(0, ErrorNumberTooLong, "number exceeds the limit of %d characters", max)
+				}
+				token.Type = TokenNumber
+			case eol:
+				var err error
+				if value, err = t.validUTF8(raw); err != nil {
+					return 0, err
+				}
+				token.Type = TokenString
+			default:
+				// Part of a string, like the comma in "a, b"
+				j++
+				continue
+			}
+			token.Value, token.Raw = value, raw
+			return end, nil
+		}
+		if char < 0x20 && char != '\t' {
+			return 0, t.errorAt(j, ErrorControlCharacter, "invalid character in string: control character 0x%02X", char)
+		}
+		j++
+		if max := t.limits.MaxStringLength; max > 0 && j > max {
+			return 0, t.errorAt(0, ErrorStringTooLong, "string exceeds the limit of %d bytes", max)
 		}
-		if len(str) == len(expected) {
+	}
+}
+
+// readMultiline scans an Hjson multiline string, enclosed in three single
+// quotes on each side. Its lines lose as much of their indentation as the
+// opening quotes have, and line breaks right after the opening quotes and
+// right before the closing ones are not part of it. Nothing is escaped.
+func (t *Tokenizer) readMultiline(token *Token) (int, error) {
+	t.scratch = t.scratch[:0]
+	indent := t.position.Column - 1
+
+	j := 3
+	for {
+		char, ok := t.byteAt(j)
+		if !ok || char > ' ' || char == '\n' {
 			break
 		}
+		j++
+	}
+	if char, _ := t.byteAt(j); char == '\n' {
+		j = t.indentAt(j+1, indent)
 	}
 
-	if str == "false" {
-		return Token{Type: TokenFalse, Value: "false"}, nil
+	for {
+		char, ok := t.byteAt(j)
+		switch {
+		case !ok:
+			return 0, t.errorAt(0, ErrorUnterminatedString, "unterminated multiline string")
+		case char == '\'':
+			second, _ := t.byteAt(j + 1)
+			third, _ := t.byteAt(j + 2)
+			if second == '\'' && third == '\'' {
+				value, err := t.validUTF8(string(bytes.TrimSuffix(t.scratch, []byte("\n"))))
+				if err != nil {
+					return 0, err
+				}
+				token.Type, token.Value, token.Raw = TokenString, value, t.text(0, j+3)
+				return j + 3, nil
+			}
+			t.scratch = append(t.scratch, char)
+			j++
+		case char == '\n':
+			t.scratch = append(t.scratch, char)
+			j = t.indentAt(j+1, indent)
+		case char == '\r':
+			j++
+		default:
+			t.scratch = append(t.scratch, char)
+			j++
+		}
+		if max := t.limits.MaxStringLength; max > 0 && len(t.scratch) > max {
+			return 0, t.errorAt(0, ErrorStringTooLong, "string exceeds the limit of %d bytes", max)
+		}
 	}
-	return Token{}, fmt.Errorf("incorrect spelling for 'false'")
 }
 
-// func (t *Tokenizer) ReadNumber(start byte) (Token, error) {
-// 	var numStr string
-// 	numStr += string(start)
-
-// 	for t.scanner.Scan() {
-// 		char := t.scanner.Bytes()[0]
-// 		if unicode.IsDigit(rune(char)) || char == '.' {
-// 			if len(numStr) > 2 && numStr[0] == '0' && numStr[1] != '.' {
-// 				return Token{Type: TokenNumber, Value: numStr}, fmt.Errorf("invalid leading 0 in number")
-// 			}
-// 			numStr += string(char)
-// 		} else {
-// 			t.buffer = &char
-// 			break
-// 		}
-// 	}
+// indentAt skips up to indent whitespace characters on the line starting j
+// bytes past the next unread one and returns the index after them
+func (t *Tokenizer) indentAt(j, indent int) int {
+	for ; indent > 0; indent-- {
+		if char, ok := t.byteAt(j); !ok || char > ' ' || char == '\n' {
+			break
+		}
+		j++
+	}
+	return j
+}
 
-// 	return Token{Type: TokenNumber, Value: numStr}, nil
-// }
+// commentAt reports whether the '/' j bytes past the next unread one starts
+// a // or /* comment
+func (t *Tokenizer) commentAt(j int) bool {
+	next, _ := t.byteAt(j + 1)
+	return next == '/' || next == '*'
+}
 
-func (t *Tokenizer) ReadNumber(start byte) (Token, error) {
-	var numStr string
-	numStr += string(start)
-	var currentChar byte
-	furtherProcess := true
+// validUTF8 applies the UTF-8 policy to text that is not enclosed in quotes
+func (t *Tokenizer) validUTF8(s string) (string, error) {
+	if utf8.ValidString(s) {
+		return s, nil
+	}
+	if t.options.InvalidUTF8 != UTF8Replace {
+		return "", t.errorAt(0, ErrorInvalidUTF8, "invalid UTF-8 in string")
+	}
+	return strings.ToValidUTF8(s, "\uFFFD"), nil
+}
 
-	for t.scanner.Scan() {
-		currentChar = t.scanner.Bytes()[0]
+// readIdentifier scans a JSON5 identifier, an ECMAScript 5.1 IdentifierName.
+// The ones that spell true, false, null, Infinity and NaN are those values.
+func (t *Tokenizer) readIdentifier(token *Token) (int, error) {
+	t.scratch = t.scratch[:0]
+	escaped := false
+	segment := 0 // start of the bytes not yet copied to t.scratch
 
-		// Integer parsing
-		if currentChar != '.' && currentChar != 'e' && currentChar != 'E' {
-			if unicode.IsDigit(rune(currentChar)) {
-				numStr += string(currentChar)
-			} else {
-				t.buffer = &currentChar
-				furtherProcess = false
-				break
+	j := 0
+	for {
+		char, ok := t.byteAt(j)
+		if !ok {
+			break
+		}
+		r, n := rune(char), 1
+		if char == '\\' {
+			u, _ := t.byteAt(j + 1)
+			hex, ok := t.hexAt(j + 2)
+			if u != 'u' || !ok {
+				return 0, t.errorAt(j, ErrorInvalidEscape, "invalid escape in identifier")
+			}
+			r, n = hex, 6
+		} else if char >= utf8.RuneSelf {
+			r, n = t.decodeRuneAt(j)
+		}
+		if !isIdentifierStart(r) && (j == 0 || !isIdentifierPart(r)) {
+			if char == '\\' {
+				return 0, t.errorAt(j, ErrorInvalidEscape, "escape of U+%04X in identifier", r)
 			}
-		} else {
 			break
 		}
+		if char == '\\' {
+			escaped = true
+			t.scratch = append(t.scratch, t.buf[t.pos+segment:t.pos+j]...)
+			t.scratch = utf8.AppendRune(t.scratch, r)
+			segment = j + n
+		}
+		j += n
+		if max := t.limits.MaxStringLength; max > 0 && j > max {
+			return 0, t.errorAt(0, ErrorStringTooLong, "identifier exceeds the limit of %d bytes", max)
+		}
+	}
+	if j == 0 {
+		return 0, t.errorAt(0, ErrorUnexpectedCharacter, "unexpected character: %c", t.runeAt(0))
 	}
 
-	// Test for leading 0
-	if len(numStr) > 1 && numStr[0] == '0' {
-		return Token{Type: TokenNumber, Value: numStr}, fmt.Errorf("invalid leading 0 found")
+	raw := t.text(0, j)
+	value := raw
+	if escaped {
+		t.scratch = append(t.scratch, t.buf[t.pos+segment:t.pos+j]...)
+		value = string(t.scratch)
+	}
+	token.Type, token.Value, token.Raw = TokenIdentifier, value, raw
+	switch raw {
+	case "true":
+		token.Type = TokenTrue
+	case "false":
+		token.Type = TokenFalse
+	case "null":
+		token.Type = TokenNull
+	case "Infinity", "NaN":
+		token.Type = TokenNumber
 	}
+	return j, nil
+}
 
-	// Fraction parsing
-	if furtherProcess && currentChar == '.' {
-		numStr += string(currentChar)
-		for t.scanner.Scan() {
-			currentChar = t.scanner.Bytes()[0]
+// readJSON5Number scans a JSON5 number. On top of JSON numbers these may
+// have a leading +, a decimal point with digits on one side only, be
+// hexadecimal integers like 0x1F, or be Infinity or NaN. The token's Value
+// is the number written as JSON, so the parser and everything after it only
+// ever see JSON numbers.
+func (t *Tokenizer) readJSON5Number(token *Token) (int, error) {
+	j := 0
+	sign, _ := t.byteAt(0)
+	if sign == '+' || sign == '-' {
+		j++
+	}
+	minus := ""
+	if sign == '-' {
+		minus = "-"
+	}
 
-			if currentChar != 'e' && currentChar != 'E' {
-				if unicode.IsDigit(rune(currentChar)) {
-					numStr += string(currentChar)
-				} else {
-					t.buffer = &currentChar
-					furtherProcess = false
-					break
-				}
-			} else {
-				break
+	char, ok := t.byteAt(j)
+	next, _ := t.byteAt(j + 1)
+	switch {
+	case ok && (char == 'I' || char == 'N'):
+		return t.readNonFinite(token, j, minus)
+	case ok && char == '0' && (next == 'x' || next == 'X'):
+		return t.readHexNumber(token, j, minus)
+	}
+
+	// Integer part, which may be empty when a fraction follows
+	start := j
+	switch {
+	case ok && char == '0':
+		j++
+		if char, ok := t.byteAt(j); ok && isDigit(char) {
+			return 0, t.errorAt(j-1, ErrorInvalidNumber, "invalid leading 0 found")
+		}
+	case ok && isDigit(char):
+		j = t.digitsAt(j)
+	}
+	integer := j - start
+
+	// Fraction part, which may be empty after an integer part
+	point, fraction := j, 0
+	if char, ok := t.byteAt(j); ok && char == '.' {
+		j++
+		fraction = t.digitsAt(j) - j
+		j += fraction
+	}
+	if integer == 0 && fraction == 0 {
+		return 0, t.errorAt(start, ErrorInvalidNumber, "expected digit in number")
+	}
+	end := j
+
+	// Exponent part
+	if char, ok := t.byteAt(j); ok && (char == 'e' || char == 'E') {
+		j++
+		char, ok := t.byteAt(j)
+		if ok && (char == '+' || char == '-') {
+			j++
+			if char, ok := t.byteAt(j); !ok || !isDigit(char) {
+				return 0, t.errorAt(j, ErrorInvalidNumber, "invalid sign with no numbers")
 			}
+		} else if !ok || !isDigit(char) {
+			return 0, t.errorAt(j, ErrorInvalidNumber, "invalid exponential number")
 		}
+		j = t.digitsAt(j)
 	}
 
-	// Exponent parsing
-	if furtherProcess && (currentChar == 'e' || currentChar == 'E') {
-		numStr += string(currentChar)
+	if max := t.limits.MaxNumberLength; max > 0 && j > max {
+		return 0, t.errorAt(0, ErrorNumberTooLong, "number exceeds the limit of %d characters", max)
+	}
+	if t.needMore {
+		// The number may go on in the next chunk
+		return 0, ErrNeedMoreData
+	}
+
+	raw := t.text(0, j)
+	value := raw
+	if sign == '+' || integer == 0 || (point < end && fraction == 0) {
+		digits := raw[start:point]
+		if integer == 0 {
+			digits = "0"
+		}
+		if fraction > 0 {
+			digits = raw[start:point] + raw[point:end]
+			if integer == 0 {
+				digits = "0" + raw[point:end]
+			}
+		}
+		value = minus + digits + raw[end:]
+	}
+	token.Type, token.Value, token.Raw = TokenNumber, value, raw
+	return j, nil
+}
 
-		if !t.scanner.Scan() {
-			return Token{Type: TokenNumber, Value: numStr}, fmt.Errorf("couldn't parse number")
+// readHexNumber scans the hexadecimal integer starting j bytes past the next
+// unread one, after its sign
+func (t *Tokenizer) readHexNumber(token *Token, j int, minus string) (int, error) {
+	j += 2
+	start := j
+	for {
+		char, ok := t.byteAt(j)
+		if !ok || !isHexDigit(char) {
+			break
+		}
+		j++
+		if max := t.limits.MaxNumberLength; max > 0 && j > max {
+			return 0, t.errorAt(0, ErrorNumberTooLong, "number exceeds the limit of %d characters", max)
 		}
+	}
+	if j == start {
+		return 0, t.errorAt(j, ErrorInvalidNumber, "expected hexadecimal digit in number")
+	}
 
-		currentChar = t.scanner.Bytes()[0]
+	raw := t.text(0, j)
+	n, _ := new(big.Int).SetString(raw[start:], 16)
+	token.Type, token.Value, token.Raw = TokenNumber, minus+n.String(), raw
+	return j, nil
+}
 
-		if currentChar == '+' || currentChar == '-' || unicode.IsDigit(rune(currentChar)) {
-			numStr += string(currentChar)
-			for t.scanner.Scan() {
-				currentChar = t.scanner.Bytes()[0]
+// readNonFinite scans Infinity or NaN starting j bytes past the next unread
+// one, after its sign. NaN has no sign once read.
+func (t *Tokenizer) readNonFinite(token *Token, j int, minus string) (int, error) {
+	literal := "Infinity"
+	if char, _ := t.byteAt(j); char == 'N' {
+		literal, minus = "NaN", ""
+	}
+	for i := 0; i < len(literal); i++ {
+		if char, ok := t.byteAt(j + i); !ok || char != literal[i] {
+			return 0, t.errorAt(j+i, ErrorInvalidLiteral, "incorrect spelling for '%s'", literal)
+		}
+	}
+	j += len(literal)
+	token.Type, token.Value, token.Raw = TokenNumber, minus+literal, t.text(0, j)
+	return j, nil
+}
 
-				if unicode.IsDigit(rune(currentChar)) {
-					numStr += string(currentChar)
-				} else {
-					t.buffer = &currentChar
-					furtherProcess = false
-					break
-				}
-			}
-		} else {
-			return Token{Type: TokenNumber, Value: numStr}, fmt.Errorf("invalid exponential number")
+// digitsAt skips the run of digits starting j bytes past the next unread one
+// and returns the index just after it
+func (t *Tokenizer) digitsAt(j int) int {
+	start := j
+	if p, ok := t.resume(start); ok {
+		j = start + p.j
+	}
+	for {
+		for t.pos+j < len(t.buf) && isDigit(t.buf[t.pos+j]) {
+			j++
+		}
+		// Stop reading once the number is known to be too long
+		if max := t.limits.MaxNumberLength; max > 0 && j > max {
+			return j
 		}
+		if t.pos+j < len(t.buf) || !t.fill() {
+			t.suspend(start, progress{j: j - start})
+			return j
+		}
+	}
+}
 
-		// check for invalid sign
-		if numStr[len(numStr)-1] == '+' || numStr[len(numStr)-1] == '-' {
-			return Token{Type: TokenNumber, Value: numStr}, fmt.Errorf("Invalid sign with no numbers")
+func isWhitespace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// isPunctuator reports whether b is one of the characters that structure
+// JSON, which unquoted Hjson keys cannot contain
+func isPunctuator(b byte) bool {
+	return b == '{' || b == '}' || b == '[' || b == ']' || b == ',' || b == ':'
+}
+
+// isNumber reports whether s is a JSON number
+func isNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && isDigit(s[i]):
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		start := i
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		if i == start {
+			return false
 		}
 	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
 
-	return Token{Type: TokenNumber, Value: numStr}, nil
+// isIdentifierStart reports whether r can start an ECMAScript 5.1 identifier
+func isIdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+// isIdentifierPart reports whether r can continue an ECMAScript 5.1
+// identifier
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200C' || r == '\u200D'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func hexValue(b byte) rune {
+	switch {
+	case b >= 'a':
+		return rune(b-'a') + 10
+	case b >= 'A':
+		return rune(b-'A') + 10
+	}
+	return rune(b - '0')
 }
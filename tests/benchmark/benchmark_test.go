@@ -0,0 +1,125 @@
+package benchmark
+
+import (
+	"bytes"
+	"fmt"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+// largeDocument builds an object of records of roughly size bytes
+func largeDocument(size int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i := 0; buf.Len() < size; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, `  "record-%d": {"id": %d, "name": "record \u00e9 %d", "score": %d.%de-3, "active": %t, "tags": ["alpha", "beta\ngamma"], "parent": null}`, i, i, i, i*7, i%100, i%2 == 0)
+	}
+	buf.WriteString("\n}")
+	return buf.Bytes()
+}
+
+func longString(size int) []byte {
+	return []byte(`["` + strings.Repeat("abcdefghij", size/10) + `"]`)
+}
+
+func benchmarkParse(b *testing.B, input []byte) {
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := parser.Parse(tokenizer.NewTokenizerFromReader(bytes.NewReader(input))); err != nil {
+			b.Fatalf("Expected successful parsing but got: %v", err)
+		}
+	}
+}
+
+func BenchmarkParseLargeDocument(b *testing.B) {
+	benchmarkParse(b, largeDocument(4<<20))
+}
+
+func BenchmarkParseLongString(b *testing.B) {
+	benchmarkParse(b, longString(1<<20))
+}
+
+func BenchmarkParseValueLargeDocument(b *testing.B) {
+	input := largeDocument(4 << 20)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseValue(tokenizer.NewTokenizerFromReader(bytes.NewReader(input))); err != nil {
+			b.Fatalf("Expected successful parsing but got: %v", err)
+		}
+	}
+}
+
+func BenchmarkTokenizeLargeDocument(b *testing.B) {
+	input := largeDocument(4 << 20)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t := tokenizer.NewTokenizerFromReader(bytes.NewReader(input))
+		for {
+			token, err := t.NextToken()
+			if err != nil {
+				b.Fatalf("Expected successful tokenizing but got: %v", err)
+			}
+			if token.Type == tokenizer.TokenEOF {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkParseBytesLargeDocument(b *testing.B) {
+	input := largeDocument(4 << 20)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := parser.Parse(tokenizer.NewTokenizerFromBytes(input)); err != nil {
+			b.Fatalf("Expected successful parsing but got: %v", err)
+		}
+	}
+}
+
+func BenchmarkParseValueBytesLargeDocument(b *testing.B) {
+	input := largeDocument(4 << 20)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseValue(tokenizer.NewTokenizerFromBytes(input)); err != nil {
+			b.Fatalf("Expected successful parsing but got: %v", err)
+		}
+	}
+}
+
+// manyMembers builds an object of n members, the second half repeating the
+// keys of the first
+func manyMembers(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `"key-%d": %d`, i%(n/2), i)
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+func BenchmarkParseValueKeepLastManyMembers(b *testing.B) {
+	input := manyMembers(80000)
+	opts := parser.DefaultOptions()
+	opts.DuplicateKeys = parser.DuplicateKeepLast
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseValueWithOptions(tokenizer.NewTokenizerFromBytes(input), opts); err != nil {
+			b.Fatalf("Expected successful parsing but got: %v", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package buffering
+
+import (
+	"bytes"
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestChunkBoundaries checks that reading the input one byte at a time gives
+// the same result as reading it in one go, so no token depends on where the
+// reader happens to split the input
+func TestChunkBoundaries(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/tests/*/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Unable to list test files: %v", err)
+	}
+
+	for _, filename := range files {
+		t.Run(filename, func(t *testing.T) {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("Unable to read file: %v", err)
+			}
+
+			whole := parser.Parse(tokenizer.NewTokenizerFromReader(bytes.NewReader(data)))
+			oneByte := parser.Parse(tokenizer.NewTokenizerFromReader(iotest.OneByteReader(bytes.NewReader(data))))
+			if (whole == nil) != (oneByte == nil) || (whole != nil && whole.Error() != oneByte.Error()) {
+				t.Errorf("Expected the same result but got %v and %v", whole, oneByte)
+			}
+		})
+	}
+}
+
+func TestTokensLargerThanBuffer(t *testing.T) {
+	text := strings.Repeat("0123456789\\n\\u00e9", 20000)
+	input := `["` + text + `", 1` + strings.Repeat("0", 100000) + `]`
+
+	value, err := parser.ParseValue(tokenizer.NewTokenizerFromReader(iotest.HalfReader(strings.NewReader(input))))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+
+	expected := strings.Repeat("0123456789\né", 20000)
+	if s, _ := value.Index(0).Str(); s != expected {
+		t.Errorf("Expected the long string to be decoded, got %d bytes instead of %d", len(s), len(expected))
+	}
+	if literal, _ := value.Index(1).Number(); len(literal) != 100001 {
+		t.Errorf("Expected a 100001 digit number but got %d digits", len(literal))
+	}
+}
+
+func TestReadError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	err := parser.Parse(tokenizer.NewTokenizerFromReader(iotest.ErrReader(readErr)))
+
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindRead {
+		t.Fatalf("Expected a read error but got: %v", err)
+	}
+	if !errors.Is(err, readErr) {
+		t.Errorf("Expected the reader's error to be wrapped but got: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package bytes
+
+import (
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"os"
+	"testing"
+	"unsafe"
+)
+
+func TestTokensShareInput(t *testing.T) {
+	input := []byte(`{"plain": -12.5e3, "escaped": "a\nb"}`)
+	tok := tokenizer.NewTokenizerFromBytes(input)
+
+	var tokens []tokenizer.Token
+	for {
+		token, err := tok.NextToken()
+		if err != nil {
+			t.Fatalf("Expected successful tokenizing but got: %v", err)
+		}
+		if token.Type == tokenizer.TokenEOF {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+
+	shared := func(s string, offset int64) bool {
+		return unsafe.StringData(s) == &input[offset]
+	}
+	if key := tokens[1]; key.Value != "plain" || !shared(key.Value, key.Start.Offset+1) {
+		t.Errorf("Expected the key to point into the input")
+	}
+	if number := tokens[3]; number.Value != "-12.5e3" || !shared(number.Value, number.Start.Offset) {
+		t.Errorf("Expected the number to point into the input")
+	}
+	if escaped := tokens[7]; escaped.Value != "a\nb" || shared(escaped.Value, escaped.Start.Offset+1) {
+		t.Errorf("Expected the escaped string to be decoded into its own memory")
+	}
+}
+
+func TestNoAllocationsForPlainTokens(t *testing.T) {
+	input := []byte(`["alpha", "beta", 123, true, null, {"key": 4.5}]`)
+	allocs := testing.AllocsPerRun(100, func() {
+		tok := tokenizer.NewTokenizerFromBytes(input)
+		for {
+			token, err := tok.NextToken()
+			if err != nil || token.Type == tokenizer.TokenEOF {
+				return
+			}
+		}
+	})
+	// Only the tokenizer itself is allocated
+	if allocs > 1 {
+		t.Errorf("Expected at most 1 allocation but got %v", allocs)
+	}
+}
+
+func TestSameResultsAsReader(t *testing.T) {
+	for _, filename := range []string{
+		"../../testdata/tests/step5/pass1.json",
+		"../../testdata/tests/step5/fail13.json",
+		"../../testdata/tests/step5/fail32.json",
+	} {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("Unable to read file: %v", err)
+		}
+		file, err := os.Open(filename)
+		if err != nil {
+			t.Fatalf("Unable to read file: %v", err)
+		}
+		defer file.Close()
+
+		fromBytes := parser.Parse(tokenizer.NewTokenizerFromBytes(data))
+		fromReader := parser.Parse(tokenizer.NewTokenizerFromReader(file))
+		if (fromBytes == nil) != (fromReader == nil) || (fromBytes != nil && fromBytes.Error() != fromReader.Error()) {
+			t.Errorf("%s: expected the same result but got %v and %v", filename, fromBytes, fromReader)
+		}
+	}
+}
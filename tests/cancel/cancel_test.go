@@ -0,0 +1,177 @@
+package cancel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bigArray returns an array of n numbers
+func bigArray(n int) string {
+	return "[" + strings.Repeat("1, ", n) + "1]"
+}
+
+func expectCanceled(t *testing.T, err error, cause error) *parser.ParseError {
+	t.Helper()
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *ParseError but got: %v", err)
+	}
+	if parseErr.Kind != parser.KindCanceled {
+		t.Errorf("Expected a %v error but got %v", parser.KindCanceled, parseErr.Kind)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected the error to wrap %v but got %v", cause, err)
+	}
+	return parseErr
+}
+
+func TestAlreadyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, tok := range map[string]*tokenizer.Tokenizer{
+		"reader": tokenizer.NewTokenizerFromReader(strings.NewReader(`[1, 2]`)),
+		"bytes":  tokenizer.NewTokenizerFromBytes([]byte(`[1, 2]`)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			parseErr := expectCanceled(t, parser.ParseContext(ctx, tok), context.Canceled)
+			if parseErr.Pos.Offset != 0 {
+				t.Errorf("Expected parsing to stop at offset 0 but got %v", parseErr.Pos)
+			}
+		})
+	}
+}
+
+func TestNotCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	value, err := parser.ParseValueContext(ctx, tokenizer.NewTokenizerFromBytes([]byte(bigArray(5000))))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if value.Len() != 5001 {
+		t.Errorf("Expected 5001 elements but got %d", value.Len())
+	}
+}
+
+// slowReader hands out its input a few bytes at a time, pausing before
+// every read
+type slowReader struct {
+	r     io.Reader
+	pause time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.pause)
+	return s.r.Read(p[:min(len(p), 16)])
+}
+
+func TestDeadlineOnSlowStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	r := &slowReader{r: strings.NewReader(bigArray(10000)), pause: time.Millisecond}
+	start := time.Now()
+	err := parser.ParseContext(ctx, tokenizer.NewTokenizerFromReader(r))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected parsing to stop soon after the deadline but it took %v", elapsed)
+	}
+
+	parseErr := expectCanceled(t, err, context.DeadlineExceeded)
+	if parseErr.Pos.Offset == 0 {
+		t.Error("Expected the error to tell how far parsing got")
+	}
+	if !strings.Contains(err.Error(), "context deadline exceeded at line 1") {
+		t.Errorf("Expected the message to name the cause and position but got %q", err.Error())
+	}
+}
+
+// cancelingReader cancels its context once all of its input has been read
+type cancelingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cancelingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p[:min(len(p), 8)])
+	if err == io.EOF {
+		c.cancel()
+	}
+	return n, err
+}
+
+func TestCanceledBeforeNextRead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &cancelingReader{r: strings.NewReader(`{"a": [1, 2, 3`), cancel: cancel}
+
+	// The document is incomplete, but cancellation is what stopped it
+	parseErr := expectCanceled(t, parser.ParseContext(ctx, tokenizer.NewTokenizerFromReader(r)), context.Canceled)
+	if parseErr.Pos.Offset != 13 {
+		t.Errorf("Expected parsing to stop at offset 13 but got %v", parseErr.Pos)
+	}
+}
+
+// In memory input is never read, so cancellation is noticed between tokens
+func TestCanceledBetweenTokens(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tok := tokenizer.NewTokenizerFromBytes([]byte(bigArray(100000)))
+	tok.SetContext(ctx)
+	d := parser.NewDecoder(tok)
+
+	for range 10 {
+		if _, err := d.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+
+	events := 0
+	var err error
+	for err == nil {
+		_, err = d.Token()
+		events++
+	}
+	expectCanceled(t, err, context.Canceled)
+	if events > 2000 {
+		t.Errorf("Expected cancellation to be noticed within a few thousand tokens but it took %d events", events)
+	}
+}
+
+func TestContextReleasedAfterParse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tok := tokenizer.NewTokenizerFromReader(strings.NewReader(`1 2 3`))
+	if err := parser.ParseContext(ctx, tok); err == nil {
+		t.Fatal("Expected unsuccessful parsing but was able to parse")
+	}
+	cancel()
+	if token, err := tok.NextToken(); err != nil || token.Value != "3" {
+		t.Errorf("Expected the tokenizer to no longer follow the context but got %v, %v", token, err)
+	}
+}
+
+// Cancellation ends a stream of documents, later calls keep returning it
+func TestCanceledEndsStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, mode := range []parser.StreamMode{parser.StreamLines, parser.StreamRecordSeparated, parser.StreamConcatenated} {
+		tok := tokenizer.NewTokenizerFromReader(strings.NewReader("1\n2\n3\n"))
+		tok.SetContext(ctx)
+		r := parser.NewDocumentReader(tok, mode)
+		first := r.Next(parser.NopHandler{})
+		expectCanceled(t, first, context.Canceled)
+		for range 3 {
+			if err := r.Next(parser.NopHandler{}); err != first {
+				t.Errorf("%v: expected the cancellation again but got %v", mode, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// binary is the json-parser command built for the tests
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "json-parser")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "json-parser")
+	build := exec.Command("go", "build", "-o", binary, "json-parser/cmd/json-parser")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "building json-parser:", err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// run feeds input to the command on stdin and returns its stdout and exit code
+func run(t *testing.T, input string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return stdout.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("Expected the command to run but got: %v", err)
+	}
+	return stdout.String(), 0
+}
+
+func TestToJSONDuplicateKeys(t *testing.T) {
+	input := `{"a": 1, "b": {"c": 1, "c": 2}, "a": 2}`
+	testCases := []struct {
+		policy   string
+		expected string
+	}{
+		{"first", "{\n  \"a\": 1,\n  \"b\": {\n    \"c\": 1\n  }\n}\n"},
+		{"last", "{\n  \"a\": 2,\n  \"b\": {\n    \"c\": 2\n  }\n}\n"},
+		{"all", "{\n  \"a\": 1,\n  \"b\": {\n    \"c\": 1,\n    \"c\": 2\n  },\n  \"a\": 2\n}\n"},
+	}
+
+	for _, tc := range testCases {
+		out, code := run(t, input, "--duplicate-keys="+tc.policy, "--to-json")
+		if code != 0 || out != tc.expected {
+			t.Errorf("%s: expected %q but got %q with exit code %d", tc.policy, tc.expected, out, code)
+		}
+	}
+
+	if out, code := run(t, input, "--duplicate-keys=reject", "--to-json"); code != 1 || out != "" {
+		t.Errorf("reject: expected exit code 1 and no output but got %q with exit code %d", out, code)
+	}
+}
+
+func TestToJSONInvalidDocument(t *testing.T) {
+	out, code := run(t, `{"a": [1, x`, "--duplicate-keys=last", "--to-json")
+	if code != 1 || out != "" {
+		t.Errorf("Expected exit code 1 and no output but got %q with exit code %d", out, code)
+	}
+}
+
+// I-JSON only reads strict JSON, other settings are reported, not ignored
+func TestIJSONConflicts(t *testing.T) {
+	for _, args := range [][]string{
+		{"--profile=i-json", "--dialect=jsonc"},
+		{"--profile=i-json", "--dialect=json5"},
+		{"--profile=i-json", "--lenient-numbers"},
+	} {
+		if _, code := run(t, "[1]", args...); code != 2 {
+			t.Errorf("%v: expected exit code 2 but got %d", args, code)
+		}
+	}
+	if _, code := run(t, "[1]", "--profile=i-json", "--dialect=json"); code != 0 {
+		t.Errorf("Expected I-JSON to read JSON but got exit code %d", code)
+	}
+}
+
+// --check-precision fails the run in every mode
+func TestCheckPrecisionInStreams(t *testing.T) {
+	for _, mode := range []string{"", "--ndjson", "--json-seq", "--concatenated"} {
+		prefix := ""
+		if mode == "--json-seq" {
+			prefix = "\x1e"
+		}
+		args := []string{"--check-precision"}
+		if mode != "" {
+			args = append(args, mode)
+		}
+		if _, code := run(t, prefix+"{\"id\": 9007199254740993}\n", args...); code != 1 {
+			t.Errorf("%q: expected exit code 1 for an imprecise number but got %d", mode, code)
+		}
+		if _, code := run(t, prefix+"{\"id\": 1}\n", args...); code != 0 {
+			t.Errorf("%q: expected exit code 0 but got %d", mode, code)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package decoder
+
+import (
+	"errors"
+	"io"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func newDecoder(input string) *parser.Decoder {
+	return parser.NewDecoder(tokenizer.NewTokenizerFromReader(strings.NewReader(input)))
+}
+
+// drain reads every event up to the end of the document or the first error
+func drain(d *parser.Decoder) ([]parser.Event, error) {
+	var events []parser.Event
+	for {
+		event, err := d.Token()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	d := newDecoder(`{"a": [1, "x", true, false, null], "b": {}}`)
+	expected := []parser.Event{
+		{Kind: parser.EventStartObject},
+		{Kind: parser.EventKey, Value: "a"},
+		{Kind: parser.EventStartArray},
+		{Kind: parser.EventNumber, Value: "1"},
+		{Kind: parser.EventString, Value: "x"},
+		{Kind: parser.EventBool, Bool: true},
+		{Kind: parser.EventBool},
+		{Kind: parser.EventNull},
+		{Kind: parser.EventEndArray},
+		{Kind: parser.EventKey, Value: "b"},
+		{Kind: parser.EventStartObject},
+		{Kind: parser.EventEndObject},
+		{Kind: parser.EventEndObject},
+	}
+
+	events, err := drain(d)
+	if err != nil {
+		t.Fatalf("Expected successful decoding but got: %v", err)
+	}
+	for i := range events {
+		events[i].Pos = tokenizer.Position{}
+	}
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected events %v but got %v", expected, events)
+	}
+	if _, err := d.Token(); err != io.EOF {
+		t.Errorf("Expected io.EOF again after the document but got %v", err)
+	}
+}
+
+func TestEventPositions(t *testing.T) {
+	d := newDecoder("[\n  {\"k\": 1}\n]")
+	var offsets []int64
+	events, err := drain(d)
+	if err != nil {
+		t.Fatalf("Expected successful decoding but got: %v", err)
+	}
+	for _, event := range events {
+		offsets = append(offsets, event.Pos.Offset)
+	}
+	if expected := []int64{0, 4, 5, 10, 11, 13}; !slices.Equal(offsets, expected) {
+		t.Errorf("Expected offsets %v but got %v", expected, offsets)
+	}
+}
+
+// The decoder must accept and reject exactly what Parse does
+func TestMatchesParse(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/tests/*/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parseErr := parser.Parse(tokenizer.NewTokenizerFromBytes(input))
+		_, decodeErr := drain(parser.NewDecoder(tokenizer.NewTokenizerFromBytes(input)))
+		if (parseErr == nil) != (decodeErr == nil) {
+			t.Errorf("%s: Parse returned %v but the decoder returned %v", file, parseErr, decodeErr)
+		}
+		if parseErr != nil && decodeErr != nil && parseErr.Error() != decodeErr.Error() {
+			t.Errorf("%s: expected error %q but got %q", file, parseErr, decodeErr)
+		}
+	}
+}
+
+func TestErrorIsSticky(t *testing.T) {
+	d := newDecoder(`[1 2]`)
+	_, err := drain(d)
+	if err == nil {
+		t.Fatal("Expected unsuccessful decoding but was able to decode")
+	}
+	if _, again := d.Token(); again != err {
+		t.Errorf("Expected the same error again but got %v", again)
+	}
+	if d.More() {
+		t.Error("Expected More to be false after an error")
+	}
+}
+
+// A malformed remainder counts as more, so a loop over More meets its error
+func TestMoreReportsMalformedRemainder(t *testing.T) {
+	d := newDecoder("[1, x]")
+	d.Token()
+	var err error
+	for d.More() && err == nil {
+		_, err = d.Token()
+	}
+	if err == nil {
+		t.Error("Expected the loop over the elements to end with an error")
+	}
+
+	// After the document
+	d = newDecoder("[1] x")
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Expected successful decoding but got: %v", err)
+	}
+	if !d.More() {
+		t.Fatal("Expected More to report the malformed remainder")
+	}
+	if _, err := d.Token(); err == nil {
+		t.Error("Expected Token to report the malformed remainder")
+	}
+	if d.More() {
+		t.Error("Expected More to be false after the error")
+	}
+}
+
+func TestDecodeSelectedElements(t *testing.T) {
+	d := newDecoder(`[{"id": 1, "keep": false}, {"id": 2, "keep": true}, [3], {"id": 4, "keep": true}]`)
+	if event, err := d.Token(); err != nil || event.Kind != parser.EventStartArray {
+		t.Fatalf("Expected the start of the array but got %v, %v", event, err)
+	}
+
+	var ids []int64
+	for d.More() {
+		value, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Expected successful decoding but got: %v", err)
+		}
+		if keep, ok := value.Get("keep"); ok {
+			if b, _ := keep.Bool(); b {
+				id, _ := value.Get("id")
+				n, _ := id.Int64()
+				ids = append(ids, n)
+			}
+		}
+	}
+	if !slices.Equal(ids, []int64{2, 4}) {
+		t.Errorf("Expected ids [2 4] but got %v", ids)
+	}
+
+	if event, err := d.Token(); err != nil || event.Kind != parser.EventEndArray {
+		t.Fatalf("Expected the end of the array but got %v, %v", event, err)
+	}
+	if d.More() {
+		t.Error("Expected no more values after the document")
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the document but got %v", err)
+	}
+}
+
+func TestSkipMemberValues(t *testing.T) {
+	d := newDecoder(`{"skip": {"deep": [1, [2, {"x": 3}]]}, "want": "yes", "also": [4]}`)
+	if _, err := d.Token(); err != nil {
+		t.Fatal(err)
+	}
+
+	var found string
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.Kind != parser.EventKey {
+			t.Fatalf("Expected a key but got %v", key.Kind)
+		}
+		if key.Value != "want" {
+			if err := d.Skip(); err != nil {
+				t.Fatalf("Expected successful skipping but got: %v", err)
+			}
+			continue
+		}
+		event, err := d.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found = event.Value
+	}
+	if found != "yes" {
+		t.Errorf("Expected to find \"yes\" but got %q", found)
+	}
+	if _, err := drain(d); err != nil {
+		t.Errorf("Expected the rest of the document to be valid but got: %v", err)
+	}
+}
+
+func TestSkipChecksGrammar(t *testing.T) {
+	d := newDecoder(`[[1, 2,], 3]`)
+	d.Token()
+	if err := d.Skip(); err == nil {
+		t.Error("Expected the invalid subtree to be reported")
+	}
+}
+
+func TestDecodeNeedsValue(t *testing.T) {
+	d := newDecoder(`{"a": 1}`)
+	d.Token()
+	if _, err := d.Decode(); !errors.Is(err, parser.ErrNoValue) {
+		t.Errorf("Expected ErrNoValue at a key but got %v", err)
+	}
+	if event, err := d.Token(); err != nil || event.Kind != parser.EventKey {
+		t.Errorf("Expected the key to be left unread but got %v, %v", event, err)
+	}
+}
+
+func TestDecodeWholeDocument(t *testing.T) {
+	value, err := newDecoder(`"just a string"`).Decode()
+	if err != nil {
+		t.Fatalf("Expected successful decoding but got: %v", err)
+	}
+	if s, _ := value.Str(); s != "just a string" {
+		t.Errorf("Expected the string back but got %q", s)
+	}
+}
+
+// Token only reads as far as the event it returns needs
+func TestReadsLazily(t *testing.T) {
+	r := io.MultiReader(strings.NewReader(`[1, `), iotest.ErrReader(errors.New("read past the first element")))
+	d := parser.NewDecoder(tokenizer.NewTokenizerFromReader(r))
+	for range 2 {
+		if _, err := d.Token(); err != nil {
+			t.Fatalf("Expected successful decoding but got: %v", err)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.MaxDepth = 2
+	opts.DuplicateKeys = parser.DuplicateReject
+
+	testCases := []struct {
+		input string
+		kind  parser.ErrorKind
+	}{
+		{`[[[1]]]`, parser.KindNestingLimit},
+		{`{"a": 1, "a": 2}`, parser.KindDuplicateKey},
+	}
+	for _, tc := range testCases {
+		_, err := drain(parser.NewDecoderWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(tc.input)), opts))
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Kind != tc.kind {
+			t.Errorf("%s: expected a %v error but got %v", tc.input, tc.kind, err)
+		}
+	}
+}
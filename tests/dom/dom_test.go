@@ -0,0 +1,97 @@
+package dom
+
+import (
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func parseString(t *testing.T, input string) *parser.Value {
+	t.Helper()
+	value, err := parser.ParseValue(tokenizer.NewTokenizerFromReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	return value
+}
+
+func TestParseValueBuildsTree(t *testing.T) {
+	root := parseString(t, `{"name": "json", "version": 2, "tags": ["a", "b"], "stable": true, "parent": null, "meta": {}}`)
+
+	if root.Kind() != parser.ObjectValue {
+		t.Fatalf("Expected an object but got %v", root.Kind())
+	}
+	if keys := root.Keys(); !slices.Equal(keys, []string{"name", "version", "tags", "stable", "parent", "meta"}) {
+		t.Errorf("Expected keys in document order but got %v", keys)
+	}
+
+	name, _ := root.Get("name")
+	if s, ok := name.Str(); !ok || s != "json" {
+		t.Errorf("Expected name to be \"json\" but got %v", s)
+	}
+	version, _ := root.Get("version")
+	if i, ok := version.Int64(); !ok || i != 2 {
+		t.Errorf("Expected version to be 2 but got %v", i)
+	}
+	stable, _ := root.Get("stable")
+	if b, ok := stable.Bool(); !ok || !b {
+		t.Errorf("Expected stable to be true")
+	}
+	parent, _ := root.Get("parent")
+	if !parent.IsNull() {
+		t.Errorf("Expected parent to be null but got %v", parent.Kind())
+	}
+	meta, _ := root.Get("meta")
+	if meta.Kind() != parser.ObjectValue || meta.Len() != 0 {
+		t.Errorf("Expected meta to be an empty object")
+	}
+
+	tags, _ := root.Get("tags")
+	var collected []string
+	for _, tag := range tags.Elements() {
+		s, _ := tag.Str()
+		collected = append(collected, s)
+	}
+	if !slices.Equal(collected, []string{"a", "b"}) {
+		t.Errorf("Expected tags [a b] but got %v", collected)
+	}
+}
+
+func TestWrongKindAccessors(t *testing.T) {
+	root := parseString(t, `[1.5, "x"]`)
+
+	if _, ok := root.Str(); ok {
+		t.Errorf("Expected Str to fail on an array")
+	}
+	if f, ok := root.Index(0).Float64(); !ok || f != 1.5 {
+		t.Errorf("Expected 1.5 but got %v", f)
+	}
+	if _, ok := root.Index(0).Int64(); ok {
+		t.Errorf("Expected Int64 to fail on 1.5")
+	}
+	if _, ok := root.Index(1).Bool(); ok {
+		t.Errorf("Expected Bool to fail on a string")
+	}
+	if root.Index(2) != nil {
+		t.Errorf("Expected nil for an out of range index")
+	}
+}
+
+func TestParseValueFromFile(t *testing.T) {
+	file, err := os.Open("../../testdata/tests/step5/pass1.json")
+	if err != nil {
+		t.Fatalf("Unable to read file: %v", err)
+	}
+	defer file.Close()
+
+	root, err := parser.ParseValue(tokenizer.NewTokenizerFromReader(file))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if root.Kind() != parser.ArrayValue || root.Len() != 20 {
+		t.Errorf("Expected an array of 20 elements but got %v of %d", root.Kind(), root.Len())
+	}
+}
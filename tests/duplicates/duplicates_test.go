@@ -0,0 +1,186 @@
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+const document = `{"a": 1, "b": {"a": 2}, "a": [3], "a": 4}`
+
+func parseValue(t *testing.T, policy parser.DuplicateKeyPolicy) *parser.Value {
+	t.Helper()
+	opts := parser.DefaultOptions()
+	opts.DuplicateKeys = policy
+	value, err := parser.ParseValueWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(document)), opts)
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	return value
+}
+
+func TestRejectNamesBothPositions(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.DuplicateKeys = parser.DuplicateReject
+	err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader("{\"a\": 1,\n \"a\": 2}")), opts)
+
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *ParseError but got: %v", err)
+	}
+	if parseErr.Kind != parser.KindDuplicateKey {
+		t.Errorf("Expected a %v error but got %v", parser.KindDuplicateKey, parseErr.Kind)
+	}
+	if want := (tokenizer.Position{Offset: 1, Line: 1, Column: 2}); parseErr.Previous != want {
+		t.Errorf("Expected the first occurrence at %v but got %v", want, parseErr.Previous)
+	}
+	if want := (tokenizer.Position{Offset: 10, Line: 2, Column: 2}); parseErr.Pos != want {
+		t.Errorf("Expected the second occurrence at %v but got %v", want, parseErr.Pos)
+	}
+	want := `duplicate key "a", first defined at line 1, column 2 (offset 1) at line 2, column 2 (offset 10)`
+	if err.Error() != want {
+		t.Errorf("Expected message %q but got %q", want, err.Error())
+	}
+}
+
+func TestRejectNestedObjectsAreSeparate(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.DuplicateKeys = parser.DuplicateReject
+	for _, input := range []string{`{"a": {"a": 1}}`, `[{"a": 1}, {"a": 2}]`, `{"a": 1, "A": 2}`} {
+		if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), opts); err != nil {
+			t.Errorf("%s: expected successful parsing but got: %v", input, err)
+		}
+	}
+}
+
+func TestRejectComparesDecodedKeys(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.DuplicateKeys = parser.DuplicateReject
+	err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`{"a": 1, "\u0061": 2}`)), opts)
+	if err == nil {
+		t.Fatal("Expected unsuccessful parsing but was able to parse")
+	}
+}
+
+func TestWarn(t *testing.T) {
+	var warnings []*parser.ParseError
+	opts := parser.DefaultOptions()
+	opts.DuplicateKeys = parser.DuplicateWarn
+	opts.OnWarning = func(warning *parser.ParseError) {
+		warnings = append(warnings, warning)
+	}
+
+	value, err := parser.ParseValueWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(document)), opts)
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings but got %d", len(warnings))
+	}
+	for _, warning := range warnings {
+		if warning.Previous.Offset != 1 {
+			t.Errorf("Expected the first occurrence at offset 1 but got %v", warning.Previous)
+		}
+	}
+	if len(value.GetAll("a")) != 3 {
+		t.Errorf("Expected every member to be kept but got %d", len(value.GetAll("a")))
+	}
+}
+
+func TestKeepAll(t *testing.T) {
+	value := parseValue(t, parser.DuplicateKeepAll)
+	if value.Len() != 4 {
+		t.Fatalf("Expected 4 members but got %d", value.Len())
+	}
+	values := value.GetAll("a")
+	if len(values) != 3 || values[0].Kind() != parser.NumberValue || values[1].Kind() != parser.ArrayValue || values[2].Kind() != parser.NumberValue {
+		t.Errorf("Expected the three values of \"a\" in document order")
+	}
+	if first, _ := value.Get("a"); first != values[0] {
+		t.Errorf("Expected Get to return the first value")
+	}
+}
+
+func TestKeepFirst(t *testing.T) {
+	value := parseValue(t, parser.DuplicateKeepFirst)
+	if keys := strings.Join(value.Keys(), ","); keys != "a,b" {
+		t.Errorf("Expected keys a,b but got %s", keys)
+	}
+	a, _ := value.Get("a")
+	if n, _ := a.Int64(); n != 1 {
+		t.Errorf("Expected the first value 1 but got %d", n)
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	value := parseValue(t, parser.DuplicateKeepLast)
+	if keys := strings.Join(value.Keys(), ","); keys != "a,b" {
+		t.Errorf("Expected keys a,b but got %s", keys)
+	}
+	a, _ := value.Get("a")
+	if n, _ := a.Int64(); n != 4 {
+		t.Errorf("Expected the last value 4 but got %d", n)
+	}
+	if len(value.GetAll("a")) != 1 {
+		t.Errorf("Expected a single value for \"a\"")
+	}
+}
+
+// manyMembers writes an object of n distinct keys, each repeated once with
+// a second value
+func manyMembers(n int) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for round := range 2 {
+		for i := range n {
+			if round > 0 || i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `"key-%d": %d`, i, round)
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// Dropping duplicates stays linear in the number of members
+func TestKeepManyMembers(t *testing.T) {
+	const n = 100000
+	input := manyMembers(n)
+	for _, tc := range []struct {
+		policy parser.DuplicateKeyPolicy
+		kept   int64
+	}{
+		{parser.DuplicateKeepFirst, 0},
+		{parser.DuplicateKeepLast, 1},
+	} {
+		opts := parser.DefaultOptions()
+		opts.DuplicateKeys = tc.policy
+		value, err := parser.ParseValueWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), opts)
+		if err != nil {
+			t.Fatalf("%v: expected successful parsing but got: %v", tc.policy, err)
+		}
+		if value.Len() != n {
+			t.Errorf("%v: expected %d members but got %d", tc.policy, n, value.Len())
+		}
+		member, _ := value.Get(fmt.Sprintf("key-%d", n-1))
+		if kept, _ := member.Int64(); kept != tc.kept {
+			t.Errorf("%v: expected the value %d to be kept but got %d", tc.policy, tc.kept, kept)
+		}
+	}
+}
+
+func TestPolicyNames(t *testing.T) {
+	for _, name := range []string{"all", "reject", "warn", "first", "last"} {
+		policy, ok := parser.ParseDuplicateKeyPolicy(name)
+		if !ok || policy.String() != name {
+			t.Errorf("Expected policy %s to round-trip but got %v", name, policy)
+		}
+	}
+	if _, ok := parser.ParseDuplicateKeyPolicy("newest"); ok {
+		t.Error("Expected an unknown policy name to be rejected")
+	}
+}
@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorKinds(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		kind  parser.ErrorKind
+	}{
+		{"Empty", "", parser.KindUnexpectedEOF},
+		{"Unclosed", `{"key": "value"`, parser.KindUnexpectedEOF},
+		{"UnexpectedCharacter", `{"key": @}`, parser.KindUnexpectedCharacter},
+		{"UnterminatedString", `{"key": "value`, parser.KindUnterminatedString},
+		{"BadEscape", `["\x15"]`, parser.KindInvalidEscape},
+		{"ControlCharacter", "[\"tab\tcharacter\"]", parser.KindControlCharacter},
+		{"LeadingZero", `[013]`, parser.KindInvalidNumber},
+		{"Misspelled", `[truth]`, parser.KindInvalidLiteral},
+		{"Nesting", strings.Repeat("[", 513) + strings.Repeat("]", 513), parser.KindNestingLimit},
+		{"MissingColon", `{"key" null}`, parser.KindUnexpectedToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(tc.input)))
+			var parseErr *parser.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError but got: %v", err)
+			}
+			if parseErr.Kind != tc.kind {
+				t.Errorf("Expected error kind %q but got %q (%v)", tc.kind, parseErr.Kind, err)
+			}
+		})
+	}
+}
+
+func TestValidJsonReturnsNil(t *testing.T) {
+	err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(`{"key": [1, true, null]}`)))
+	if err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package escapes
+
+import (
+	"errors"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+func readString(input string, options tokenizer.Options) (tokenizer.Token, error) {
+	return tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), options).NextToken()
+}
+
+func TestDecodedStrings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Plain", `"plain"`, "plain"},
+		{"Simple", `"a\nb\tc\/d\\e\"f\bg\fh\ri"`, "a\nb\tc/d\\e\"f\bg\fh\ri"},
+		{"Unicode", `"caf\u00e9"`, "café"},
+		{"UpperHex", `"\u00E9\u20AC"`, "é€"},
+		{"SurrogatePair", `"\ud83d\ude00"`, "😀"},
+		{"RawUtf8", `"naïve"`, "naïve"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := readString(tc.input, tokenizer.Options{})
+			if err != nil {
+				t.Fatalf("Expected successful tokenizing but got: %v", err)
+			}
+			if token.Value != tc.expected {
+				t.Errorf("Expected %q but got %q", tc.expected, token.Value)
+			}
+			if token.Raw != tc.input {
+				t.Errorf("Expected raw text %q but got %q", tc.input, token.Raw)
+			}
+		})
+	}
+}
+
+func TestLoneSurrogates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		replaced string
+	}{
+		{"LoneHigh", `"a\ud83db"`, "a�b"},
+		{"LoneLow", `"\ude00"`, "�"},
+		{"HighAtEnd", `"\ud83d"`, "�"},
+		{"HighThenEscape", `"\ud83d\n"`, "�\n"},
+		{"TwoHighs", `"\ud83d\ud83d\ude00"`, "�😀"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readString(tc.input, tokenizer.Options{})
+			var tokErr *tokenizer.Error
+			if !errors.As(err, &tokErr) || tokErr.Kind != tokenizer.ErrorLoneSurrogate {
+				t.Errorf("Expected a lone surrogate error but got: %v", err)
+			}
+
+			token, err := readString(tc.input, tokenizer.Options{LoneSurrogates: tokenizer.SurrogateReplace})
+			if err != nil {
+				t.Fatalf("Expected successful tokenizing but got: %v", err)
+			}
+			if token.Value != tc.replaced {
+				t.Errorf("Expected %q but got %q", tc.replaced, token.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tricky = `{"kéy😀": [true, false, null, -12.5e+3, 0, "x\\\"y", {}], "n": 1234567890}`
+
+// tokenize feeds input to a feed tokenizer in chunks of size bytes and
+// returns every token, retrying after ErrNeedMoreData
+func tokenize(input []byte, size int) ([]tokenizer.Token, error) {
+	return feedTokenizer(tokenizer.NewFeedTokenizer(), input, size)
+}
+
+func feedTokenizer(tok *tokenizer.Tokenizer, input []byte, size int) ([]tokenizer.Token, error) {
+	var tokens []tokenizer.Token
+	for {
+		token, err := tok.NextToken()
+		if errors.Is(err, tokenizer.ErrNeedMoreData) {
+			if len(input) == 0 {
+				tok.Close()
+				continue
+			}
+			n := min(size, len(input))
+			tok.Feed(input[:n])
+			input = input[n:]
+			continue
+		}
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, token)
+		if token.Type == tokenizer.TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func tokenizeBytes(input []byte) ([]tokenizer.Token, error) {
+	tok := tokenizer.NewTokenizerFromBytes(input)
+	var tokens []tokenizer.Token
+	for {
+		token, err := tok.NextToken()
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, token)
+		if token.Type == tokenizer.TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func TestEverySplitPoint(t *testing.T) {
+	expected, err := tokenizeBytes([]byte(tricky))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for size := 1; size <= len(tricky); size++ {
+		tokens, err := tokenize([]byte(tricky), size)
+		if err != nil {
+			t.Fatalf("chunks of %d: expected successful tokenizing but got: %v", size, err)
+		}
+		if !slices.Equal(tokens, expected) {
+			t.Fatalf("chunks of %d: expected %v but got %v", size, expected, tokens)
+		}
+	}
+}
+
+func TestTestdataByteByByte(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/tests/*/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, expectedErr := tokenizeBytes(input)
+		tokens, err := tokenize(input, 1)
+		if !slices.Equal(tokens, expected) || fmt.Sprint(err) != fmt.Sprint(expectedErr) {
+			t.Errorf("%s: expected %v, %v but got %v, %v", file, expected, expectedErr, tokens, err)
+		}
+	}
+}
+
+func TestNeedMoreDataOrError(t *testing.T) {
+	testCases := []struct {
+		input    string
+		needMore bool
+	}{
+		{`"abc`, true},
+		{`"abc\`, true},
+		{`"\u00`, true},
+		{`"\ud83d`, true},
+		{`"\ud83d\`, true},
+		{"\"\xe2\x82", true},
+		{`tr`, true},
+		{`-`, true},
+		{`12`, true},
+		{`1.`, true},
+		{`1e+`, true},
+		{` `, true},
+		{``, true},
+		{`trX`, false},
+		{`01`, false},
+		{`"\x`, false},
+		{`x`, false},
+		{"\"a\x01", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			tok := tokenizer.NewFeedTokenizer()
+			tok.Feed([]byte(tc.input))
+			_, err := tok.NextToken()
+			if needMore := errors.Is(err, tokenizer.ErrNeedMoreData); needMore != tc.needMore {
+				t.Errorf("Expected need more data to be %v but got %v", tc.needMore, err)
+			}
+			if !tc.needMore && err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestCloseEndsInput(t *testing.T) {
+	tok := tokenizer.NewFeedTokenizer()
+	tok.Feed([]byte(`12 "abc`))
+	if token, err := tok.NextToken(); err != nil || token.Value != "12" {
+		t.Fatalf("Expected the number 12 but got %v, %v", token, err)
+	}
+	if _, err := tok.NextToken(); !errors.Is(err, tokenizer.ErrNeedMoreData) {
+		t.Fatalf("Expected need more data but got %v", err)
+	}
+	tok.Close()
+	_, err := tok.NextToken()
+	var tokErr *tokenizer.Error
+	if !errors.As(err, &tokErr) || tokErr.Kind != tokenizer.ErrorUnterminatedString {
+		t.Errorf("Expected an unterminated string after Close but got %v", err)
+	}
+}
+
+// recorder writes every event down as a short string
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) add(event string) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) StartObject() error          { return r.add("{") }
+func (r *recorder) Key(key string) error        { return r.add("key " + key) }
+func (r *recorder) EndObject() error            { return r.add("}") }
+func (r *recorder) StartArray() error           { return r.add("[") }
+func (r *recorder) EndArray() error             { return r.add("]") }
+func (r *recorder) String(s string) error       { return r.add("string " + s) }
+func (r *recorder) Number(literal string) error { return r.add("number " + literal) }
+func (r *recorder) Bool(b bool) error           { return r.add(fmt.Sprint("bool ", b)) }
+func (r *recorder) Null() error                 { return r.add("null") }
+
+func TestEventsAsSoonAsComplete(t *testing.T) {
+	r := &recorder{}
+	p := parser.NewFeedParser(tokenizer.NewFeedTokenizer(), r)
+
+	steps := []struct {
+		chunk    string
+		expected []string
+	}{
+		{`{"na`, []string{"{"}},
+		{`me": "x`, []string{"{", "key name"}},
+		{`yz", "list": [12`, []string{"{", "key name", "string xyz", "key list", "["}},
+		{`3, tr`, []string{"{", "key name", "string xyz", "key list", "[", "number 123"}},
+		{`ue]}`, []string{"{", "key name", "string xyz", "key list", "[", "number 123", "bool true", "]", "}"}},
+	}
+	for _, step := range steps {
+		if err := p.Feed([]byte(step.chunk)); err != nil {
+			t.Fatalf("%s: expected successful parsing but got: %v", step.chunk, err)
+		}
+		if !slices.Equal(r.events, step.expected) {
+			t.Fatalf("%s: expected events %q but got %q", step.chunk, step.expected, r.events)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+}
+
+func TestScalarCompletedByClose(t *testing.T) {
+	r := &recorder{}
+	p := parser.NewFeedParser(tokenizer.NewFeedTokenizer(), r)
+	if err := p.Feed([]byte(`-1.5`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 0 {
+		t.Fatalf("Expected the number to wait for more input but got %q", r.events)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if !slices.Equal(r.events, []string{"number -1.5"}) {
+		t.Errorf("Expected the number after Close but got %q", r.events)
+	}
+}
+
+func TestInvalidInputReportedByFeed(t *testing.T) {
+	p := parser.NewFeedParser(tokenizer.NewFeedTokenizer(), parser.NopHandler{})
+	if err := p.Feed([]byte(`[1, 2`)); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	err := p.Feed([]byte(` 3]`))
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindUnexpectedToken {
+		t.Fatalf("Expected an unexpected token error but got %v", err)
+	}
+	if parseErr.Pos.Offset != 6 {
+		t.Errorf("Expected the error at offset 6 but got %v", parseErr.Pos)
+	}
+	if again := p.Close(); again != err {
+		t.Errorf("Expected the same error from Close but got %v", again)
+	}
+}
+
+func TestIncompleteDocumentAtClose(t *testing.T) {
+	p := parser.NewFeedParser(tokenizer.NewFeedTokenizer(), parser.NopHandler{})
+	if err := p.Feed([]byte(`{"a": [1`)); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Close()
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindUnexpectedEOF {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+}
+
+func TestFeedMatchesParse(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/tests/*/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parseErr := parser.Parse(tokenizer.NewTokenizerFromBytes(input))
+
+		p := parser.NewFeedParser(tokenizer.NewFeedTokenizer(), parser.NopHandler{})
+		var feedErr error
+		for i := 0; i < len(input) && feedErr == nil; i += 3 {
+			feedErr = p.Feed(input[i:min(i+3, len(input))])
+		}
+		if feedErr == nil {
+			feedErr = p.Close()
+		}
+		if fmt.Sprint(parseErr) != fmt.Sprint(feedErr) {
+			t.Errorf("%s: Parse returned %v but the feed parser returned %v", file, parseErr, feedErr)
+		}
+	}
+}
+
+func TestLongTokensInSmallChunks(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(n int) (string, string) // input of about n bytes and its value
+	}{
+		{"string", func(n int) (string, string) {
+			s := strings.Repeat("a", n)
+			return `"` + s + `"`, s
+		}},
+		{"string with escapes", func(n int) (string, string) {
+			return `"` + strings.Repeat(`é\n\ud83d\ude00`, n/16) + `"`, strings.Repeat("é\n😀", n/16)
+		}},
+		{"line comment", func(n int) (string, string) {
+			s := strings.Repeat("*", n)
+			return "//" + s, s
+		}},
+		{"block comment", func(n int) (string, string) {
+			s := strings.Repeat("*", n)
+			return "/*" + s + "*/", s
+		}},
+		{"number", func(n int) (string, string) {
+			s := "1" + strings.Repeat("0", n)
+			return s, s
+		}},
+	}
+
+	for _, test := range tests {
+		var elapsed [2]time.Duration
+		for i, size := range []int{1 << 20, 4 << 20} {
+			input, expected := test.token(size)
+			start := time.Now()
+			tokens, err := feedTokenizer(tokenizer.NewFeedTokenizerWithOptions(tokenizer.Options{Dialect: tokenizer.DialectJSONC, EmitComments: true}), []byte(input), 4093)
+			elapsed[i] = time.Since(start)
+			if err != nil {
+				t.Fatalf("%s: Expected no error but got %v", test.name, err)
+			}
+			if len(tokens) != 2 || tokens[0].Value != expected {
+				t.Fatalf("%s: Expected a single token holding the %d byte value", test.name, len(expected))
+			}
+		}
+		// Four times the input takes about four times as long, rescanning the
+		// token after every chunk would take sixteen
+		if elapsed[1] > 10*elapsed[0]+50*time.Millisecond {
+			t.Errorf("%s: Expected about linear time but 1 MB took %v and 4 MB took %v", test.name, elapsed[0], elapsed[1])
+		}
+	}
+}
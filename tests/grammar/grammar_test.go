@@ -0,0 +1,76 @@
+package grammar
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func parseString(input string) error {
+	return parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(input)))
+}
+
+func TestValidDocuments(t *testing.T) {
+	for _, input := range []string{
+		`[{}, {}]`,
+		`[[], [[]], {"a": []}]`,
+		`{"a": {"b": {"c": [1, {"d": null}]}}, "e": false}`,
+		`[-0, 0.5, -1.25e+10, 3E-2, 10]`,
+		` [ "spaced" , 1 ] `,
+	} {
+		if err := parseString(input); err != nil {
+			t.Errorf("%s: expected successful parsing but got: %v", input, err)
+		}
+	}
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []tokenizer.TokenType
+	}{
+		{`[1,,2]`, []tokenizer.TokenType{tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare, tokenizer.TokenString, tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull}},
+		{`["a": 1]`, []tokenizer.TokenType{tokenizer.TokenComma, tokenizer.TokenRightSquare}},
+		{`[1 2]`, []tokenizer.TokenType{tokenizer.TokenComma, tokenizer.TokenRightSquare}},
+		{`{"a" 1}`, []tokenizer.TokenType{tokenizer.TokenColon}},
+		{`{"a": 1 "b": 2}`, []tokenizer.TokenType{tokenizer.TokenComma, tokenizer.TokenRightBrace}},
+		{`{"a": 1,}`, []tokenizer.TokenType{tokenizer.TokenString}},
+		{`{,}`, []tokenizer.TokenType{tokenizer.TokenString, tokenizer.TokenRightBrace}},
+		{`{1: 2}`, []tokenizer.TokenType{tokenizer.TokenString, tokenizer.TokenRightBrace}},
+		{`[1]]`, []tokenizer.TokenType{tokenizer.TokenEOF}},
+		{`[] []`, []tokenizer.TokenType{tokenizer.TokenEOF}},
+		{`{"a": }`, []tokenizer.TokenType{tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare, tokenizer.TokenString, tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull}},
+		{`[1, 2`, []tokenizer.TokenType{tokenizer.TokenComma, tokenizer.TokenRightSquare}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			err := parseString(tc.input)
+			var parseErr *parser.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError but got: %v", err)
+			}
+			if !slices.Equal(parseErr.Expected, tc.expected) {
+				t.Errorf("Expected %v to be expected but got %v (%v)", tc.expected, parseErr.Expected, err)
+			}
+		})
+	}
+}
+
+func TestInvalidNumbers(t *testing.T) {
+	for _, input := range []string{`[-]`, `[-01]`, `[1.]`, `[.5]`, `[1.e5]`, `[1e]`, `[+1]`} {
+		if err := parseString(input); err == nil {
+			t.Errorf("%s: expected unsuccessful parsing but was able to parse", input)
+		}
+	}
+}
+
+func TestErrorMessageNamesExpectedTokens(t *testing.T) {
+	err := parseString(`{"key" "value"}`)
+	if err == nil || !strings.Contains(err.Error(), "expected ':' but found string \"value\"") {
+		t.Errorf("Expected the message to name the expected token but got: %v", err)
+	}
+}
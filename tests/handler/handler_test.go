@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// recorder writes every event down as a short string
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) StartObject() error { r.events = append(r.events, "{"); return nil }
+func (r *recorder) Key(key string) error {
+	r.events = append(r.events, "key "+key)
+	return nil
+}
+func (r *recorder) EndObject() error  { r.events = append(r.events, "}"); return nil }
+func (r *recorder) StartArray() error { r.events = append(r.events, "["); return nil }
+func (r *recorder) EndArray() error   { r.events = append(r.events, "]"); return nil }
+func (r *recorder) String(s string) error {
+	r.events = append(r.events, "string "+s)
+	return nil
+}
+func (r *recorder) Number(literal string) error {
+	r.events = append(r.events, "number "+literal)
+	return nil
+}
+func (r *recorder) Bool(b bool) error {
+	r.events = append(r.events, fmt.Sprint("bool ", b))
+	return nil
+}
+func (r *recorder) Null() error { r.events = append(r.events, "null"); return nil }
+
+func TestEvents(t *testing.T) {
+	input := `{"name": "abc", "tags": ["x", 1.5e3, true, false, null], "empty": {}, "list": []}`
+	expected := []string{
+		"{",
+		"key name", "string abc",
+		"key tags", "[", "string x", "number 1.5e3", "bool true", "bool false", "null", "]",
+		"key empty", "{", "}",
+		"key list", "[", "]",
+		"}",
+	}
+
+	r := &recorder{}
+	if err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), r, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if !slices.Equal(r.events, expected) {
+		t.Errorf("Expected events %q but got %q", expected, r.events)
+	}
+}
+
+func TestScalarDocument(t *testing.T) {
+	r := &recorder{}
+	if err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(`"only"`)), r, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if !slices.Equal(r.events, []string{"string only"}) {
+		t.Errorf("Expected a single string event but got %q", r.events)
+	}
+}
+
+func TestEventsBeforeSyntaxError(t *testing.T) {
+	r := &recorder{}
+	err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(`[1, 2,]`)), r, parser.DefaultOptions())
+	if err == nil {
+		t.Fatal("Expected unsuccessful parsing but was able to parse")
+	}
+	if !slices.Equal(r.events, []string{"[", "number 1", "number 2"}) {
+		t.Errorf("Expected the events before the error but got %q", r.events)
+	}
+}
+
+var errFound = errors.New("found it")
+
+// finder stops the parse at the first key it is looking for
+type finder struct {
+	parser.NopHandler
+	key  string
+	keys int
+}
+
+func (f *finder) Key(key string) error {
+	f.keys++
+	if key == f.key {
+		return errFound
+	}
+	return nil
+}
+
+func TestHandlerAbortsParse(t *testing.T) {
+	// The document is invalid after the key, which is never reached
+	input := `{"a": 1, "b": 2, "needle": 3, "c": }`
+	f := &finder{key: "needle"}
+	err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), f, parser.DefaultOptions())
+
+	if !errors.Is(err, errFound) {
+		t.Fatalf("Expected the handler's error but got: %v", err)
+	}
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindHandler {
+		t.Fatalf("Expected a %v *ParseError but got: %v", parser.KindHandler, err)
+	}
+	if parseErr.Pos.Offset != 17 {
+		t.Errorf("Expected the error at the key's offset 17 but got %v", parseErr.Pos)
+	}
+	if f.keys != 3 {
+		t.Errorf("Expected 3 keys to be reported but got %d", f.keys)
+	}
+}
+
+func TestNopHandlerMatchesParse(t *testing.T) {
+	entries, err := os.ReadDir("../../testdata/tests/step5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		file := "../../testdata/tests/step5/" + entry.Name()
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		validateErr := parser.Parse(tokenizer.NewTokenizerFromBytes(input))
+		handlerErr := parser.ParseWithHandler(tokenizer.NewTokenizerFromBytes(input), parser.NopHandler{}, parser.DefaultOptions())
+		if (validateErr == nil) != (handlerErr == nil) {
+			t.Errorf("%s: Parse returned %v but ParseWithHandler returned %v", file, validateErr, handlerErr)
+		}
+	}
+}
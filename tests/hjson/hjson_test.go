@@ -0,0 +1,480 @@
+package hjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"json-parser/pkg/encoder"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func hjson(input string) *tokenizer.Tokenizer {
+	return tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectHjson})
+}
+
+// recorder writes down every event it receives
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) record(event string) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) StartObject() error          { return r.record("{") }
+func (r *recorder) Key(key string) error        { return r.record("key " + key) }
+func (r *recorder) EndObject() error            { return r.record("}") }
+func (r *recorder) StartArray() error           { return r.record("[") }
+func (r *recorder) EndArray() error             { return r.record("]") }
+func (r *recorder) String(s string) error       { return r.record(fmt.Sprintf("string %q", s)) }
+func (r *recorder) Number(literal string) error { return r.record("number " + literal) }
+func (r *recorder) Bool(b bool) error           { return r.record(fmt.Sprint(b)) }
+func (r *recorder) Null() error                 { return r.record("null") }
+
+const config = `# service configuration
+{
+  // keys and strings without quotes
+  name: my service
+  url: http://example.com/path # part of the string
+  port: 8080
+  ratio: 0.5, enabled: true
+  tags: [
+    a, b
+    c
+    "quoted"
+  ]
+  items: [1, 2, 3,]
+  'single': 'it\'s'
+  /* block
+     comment */
+  text:
+    '''
+    first line
+      indented
+    last line
+    '''
+  empty: ''
+  notnum: 12 monkeys
+  nested: { a: 1, b: null }
+}
+`
+
+const configJSON = `{
+  "name": "my service",
+  "url": "http://example.com/path # part of the string",
+  "port": 8080,
+  "ratio": 0.5, "enabled": true,
+  "tags": ["a, b", "c", "quoted"],
+  "items": [1, 2, 3],
+  "single": "it's",
+  "text": "first line\n  indented\nlast line",
+  "empty": "",
+  "notnum": "12 monkeys",
+  "nested": {"a": 1, "b": null}
+}`
+
+// An Hjson document reports the same events as the JSON it stands for
+func TestSameEventsAsJSON(t *testing.T) {
+	fromHjson, fromJSON := &recorder{}, &recorder{}
+	if err := parser.ParseWithHandler(hjson(config), fromHjson, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(configJSON)), fromJSON, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if !slices.Equal(fromHjson.events, fromJSON.events) {
+		t.Errorf("Expected events %v but got %v", fromJSON.events, fromHjson.events)
+	}
+}
+
+func TestSameDocumentAsJSON(t *testing.T) {
+	v, err := parser.ParseValue(hjson(config))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	w, _ := parser.ParseValue(tokenizer.NewTokenizerFromReader(strings.NewReader(configJSON)))
+
+	var got, expected bytes.Buffer
+	encoder.NewEncoder(&got).Encode(v)
+	encoder.NewEncoder(&expected).Encode(w)
+	if got.String() != expected.String() {
+		t.Errorf("Expected %s but got %s", expected.String(), got.String())
+	}
+
+	// The decoder walks it the same way
+	d := parser.NewDecoder(hjson(config))
+	decoded, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Expected the decoder to read it but got: %v", err)
+	}
+	got.Reset()
+	encoder.NewEncoder(&got).Encode(decoded)
+	if got.String() != expected.String() {
+		t.Errorf("Expected %s but got %s", expected.String(), got.String())
+	}
+}
+
+func TestQuotelessValues(t *testing.T) {
+	testCases := []struct {
+		line  string
+		event string
+	}{
+		{"true", "true"},
+		{"false  ", "false"},
+		{"null\t", "null"},
+		{"-12.5e+3", "number -12.5e+3"},
+		{"0", "number 0"},
+		{"trueish", `string "trueish"`},
+		{"true false", `string "true false"`},
+		{"01", `string "01"`},
+		{"1.", `string "1."`},
+		{"+1", `string "+1"`},
+		{"0x10", `string "0x10"`},
+		{"hello world   ", `string "hello world"`},
+		{"a: b", `string "a: b"`},
+		{"'not closed", ""},
+		{"x # y", `string "x # y"`},
+		{"5 # comment", "number 5"},
+		{"5 // comment", "number 5"},
+		{"5 /* comment */", "number 5"},
+		{"ünïcödé 😀", `string "ünïcödé 😀"`},
+		{"tab\tinside", `string "tab\tinside"`},
+		{"/path/to", `string "/path/to"`},
+		{"a\r", `string "a"`},
+	}
+
+	for _, tc := range testCases {
+		r := &recorder{}
+		err := parser.ParseWithHandler(hjson("{\nv: "+tc.line+"\n}"), r, parser.DefaultOptions())
+		if tc.event == "" {
+			if err == nil {
+				t.Errorf("%q: expected unsuccessful parsing but was able to parse", tc.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: expected successful parsing but got: %v", tc.line, err)
+			continue
+		}
+		if len(r.events) != 4 || r.events[2] != tc.event {
+			t.Errorf("%q: expected %s but got %v", tc.line, tc.event, r.events)
+		}
+	}
+}
+
+// A literal or number ends at a comma or a closing bracket, anything else
+// takes the rest of the line
+func TestQuotelessOnOneLine(t *testing.T) {
+	r := &recorder{}
+	if err := parser.ParseWithHandler(hjson(`{a: [1, true, null], b: 2}`), r, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	expected := []string{"{", "key a", "[", "number 1", "true", "null", "]", "key b", "number 2", "}"}
+	if !slices.Equal(r.events, expected) {
+		t.Errorf("Expected events %v but got %v", expected, r.events)
+	}
+
+	if err := parser.Parse(hjson(`{a: [x, y]}`)); err == nil {
+		t.Error("Expected [x, y]} to be read as one string, leaving the array open")
+	}
+}
+
+func TestMultilineStrings(t *testing.T) {
+	testCases := []struct {
+		input string
+		value string
+	}{
+		{"'''one line'''", "one line"},
+		{"'''\n  two\n  lines\n  '''", "  two\n  lines\n  "}, // indentation before the closing quotes stays
+		{"    '''\n    two\n      lines\n    '''", "two\n  lines"},
+		{"  '''\n      deeper\n  '''", "    deeper"},
+		{"    '''\n  shallower\n    '''", "shallower"},
+		{"'''   \nafter spaces\n'''", "after spaces"},
+		{"'''\r\nwindows\r\nlines\r\n'''", "windows\nlines"},
+		{"'''it's \"quoted\" '' \\n'''", "it's \"quoted\" '' \\n"},
+		{"'''\n\n'''", ""},
+		{"''''''", ""},
+	}
+
+	for _, tc := range testCases {
+		token, err := hjson(tc.input).NextToken()
+		if err != nil {
+			t.Errorf("%q: expected a string but got: %v", tc.input, err)
+			continue
+		}
+		if token.Type != tokenizer.TokenString || token.Value != tc.value {
+			t.Errorf("%q: expected %q but got %v %q", tc.input, tc.value, token.Type, token.Value)
+		}
+	}
+}
+
+func TestOptionalCommas(t *testing.T) {
+	for _, input := range []string{
+		"{\n a: 1\n b: 2\n}",
+		"{\n a: 1,\n b: 2,\n}",
+		"[\n 1\n 2\n]",
+		"[\n \"a\"\n 'b'\n {}\n []\n]",
+	} {
+		v, err := parser.ParseValue(hjson(input))
+		if err != nil {
+			t.Errorf("%q: expected successful parsing but got: %v", input, err)
+			continue
+		}
+		if v.Len() < 2 {
+			t.Errorf("%q: expected two members or elements but got %d", input, v.Len())
+		}
+	}
+
+	// So does the decoder
+	d := parser.NewDecoder(hjson("[\n 1\n 2\n 3\n]"))
+	d.Token()
+	count := 0
+	for d.More() {
+		if err := d.Skip(); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		count++
+	}
+	if event, err := d.Token(); err != nil || event.Kind != parser.EventEndArray || count != 3 {
+		t.Errorf("Expected 3 elements and the end of the array but got %d, %v, %v", count, event.Kind, err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "# one\n{a: 1 // two\n/* three */}"
+	tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectHjson, EmitComments: true})
+	var comments []string
+	for {
+		token, err := tok.NextToken()
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if token.Type == tokenizer.TokenEOF {
+			break
+		}
+		if token.Type == tokenizer.TokenComment {
+			comments = append(comments, token.Raw+"|"+token.Value)
+		}
+	}
+	expected := []string{"# one| one", "// two| two", "/* three */| three "}
+	if !slices.Equal(comments, expected) {
+		t.Errorf("Expected comments %q but got %q", expected, comments)
+	}
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	testCases := []struct {
+		input string
+		kind  parser.ErrorKind
+	}{
+		{"{a b: 1}", parser.KindUnexpectedToken},
+		{"{a: 1", parser.KindUnexpectedEOF},
+		{"[1 2]", parser.KindUnexpectedEOF}, // "1 2]" is a quoteless string
+		{"{: 1}", parser.KindUnexpectedToken},
+		{"[:]", parser.KindUnexpectedToken},
+		{"{a: '''open}", parser.KindUnterminatedString},
+		{"{a: 'open}", parser.KindUnterminatedString},
+		{"{a: b\x01c}", parser.KindControlCharacter},
+		{"{a: \xff}", parser.KindInvalidUTF8},
+		{"{\xff: 1}", parser.KindInvalidUTF8},
+		{"{a: 1 /* open", parser.KindUnterminatedComment},
+		{`{a: "\x"}`, parser.KindInvalidEscape},
+	}
+
+	for _, tc := range testCases {
+		err := parser.Parse(hjson(tc.input))
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a *ParseError but got: %v", tc.input, err)
+			continue
+		}
+		if parseErr.Kind != tc.kind {
+			t.Errorf("%q: expected a %v error but got %v: %v", tc.input, tc.kind, parseErr.Kind, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.MaxStringLength = 8
+	for _, input := range []string{"{a: quoteless string}", "{averyverylongkey: 1}", "{a: '''multiline string'''}"} {
+		err := parser.ParseWithOptions(hjson(input), opts)
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindStringTooLong {
+			t.Errorf("%q: expected a string too long error but got: %v", input, err)
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	expected := &recorder{}
+	if err := parser.ParseWithHandler(hjson(config), expected, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+
+	for _, size := range []int{1, 2, 3, 7, 64} {
+		r := &recorder{}
+		p := parser.NewFeedParser(tokenizer.NewFeedTokenizerWithOptions(tokenizer.Options{Dialect: tokenizer.DialectHjson}), r)
+		data := []byte(config)
+		for len(data) > 0 {
+			n := min(size, len(data))
+			if err := p.Feed(data[:n]); err != nil {
+				t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+			}
+			data = data[n:]
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+		}
+		if !slices.Equal(r.events, expected.events) {
+			t.Errorf("chunks of %d: expected events %v but got %v", size, expected.events, r.events)
+		}
+	}
+}
+
+// Converting to JSON gives a document strict JSON accepts
+func TestConvertToJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := parser.ParseWithHandler(hjson(config), encoder.NewEncoderWithOptions(&out, encoder.Options{Indent: "  "}), parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful conversion but got: %v", err)
+	}
+	d := parser.NewDecoder(tokenizer.NewTokenizerFromReader(&out))
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Expected valid JSON but got: %v", err)
+	}
+	if _, err := d.Token(); err != io.EOF {
+		t.Errorf("Expected a single document but got: %v", err)
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	for _, input := range []string{config, "{\"a\": 1\n\"b\": 2}", "[# comment\n]", "[x]"} {
+		for _, dialect := range []tokenizer.Dialect{tokenizer.DialectJSON, tokenizer.DialectJSONC, tokenizer.DialectJSON5} {
+			tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: dialect})
+			if err := parser.Parse(tok); err == nil {
+				t.Errorf("%q: expected %v to reject it", input, dialect)
+			}
+		}
+	}
+}
+
+// A root object may leave out its braces
+func TestRootWithoutBraces(t *testing.T) {
+	testCases := []struct {
+		input  string
+		braced string
+	}{
+		{"name: my service\nport: 8080\n", "{name: my service\nport: 8080\n}"},
+		{"# config\n\"quoted\": 1\n'single': x\n", "{\"quoted\": 1\n'single': x\n}"},
+		{"a:\n  '''\n  text\n  '''\nb: [1, 2]\nc: {d: null}", "{a: '''text'''\nb: [1, 2]\nc: {d: null}}"},
+		{"key : value", "{key: value\n}"},
+		{"a: 1, b: 2,", "{a: 1, b: 2}"},
+	}
+
+	for _, tc := range testCases {
+		got, expected := &recorder{}, &recorder{}
+		if err := parser.ParseWithHandler(hjson(tc.input), got, parser.DefaultOptions()); err != nil {
+			t.Errorf("%q: expected successful parsing but got: %v", tc.input, err)
+			continue
+		}
+		parser.ParseWithHandler(hjson(tc.braced), expected, parser.DefaultOptions())
+		if !slices.Equal(got.events, expected.events) {
+			t.Errorf("%q: expected events %v but got %v", tc.input, expected.events, got.events)
+		}
+
+		// The decoder sees the same braces
+		r := &recorder{}
+		p := parser.NewFeedParser(tokenizer.NewFeedTokenizerWithOptions(tokenizer.Options{Dialect: tokenizer.DialectHjson}), r)
+		for i := range len(tc.input) {
+			if err := p.Feed([]byte{tc.input[i]}); err != nil {
+				t.Fatalf("%q: expected no error but got: %v", tc.input, err)
+			}
+		}
+		if err := p.Close(); err != nil {
+			t.Errorf("%q: expected the decoder to read it but got: %v", tc.input, err)
+		}
+		if !slices.Equal(r.events, expected.events) {
+			t.Errorf("%q: expected the decoder to report %v but got %v", tc.input, expected.events, r.events)
+		}
+	}
+}
+
+func TestRootWithoutBracesTokens(t *testing.T) {
+	tok := hjson("a: 1")
+	var types []tokenizer.TokenType
+	for {
+		token, err := tok.NextToken()
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		types = append(types, token.Type)
+		if (token.Type == tokenizer.TokenLeftBrace || token.Type == tokenizer.TokenRightBrace) && token.Raw != "" {
+			t.Errorf("Expected the braces to have an empty Raw but got %q", token.Raw)
+		}
+		if token.Type == tokenizer.TokenEOF {
+			break
+		}
+	}
+	expected := []tokenizer.TokenType{tokenizer.TokenLeftBrace, tokenizer.TokenIdentifier, tokenizer.TokenColon, tokenizer.TokenNumber, tokenizer.TokenRightBrace, tokenizer.TokenEOF}
+	if !slices.Equal(types, expected) {
+		t.Errorf("Expected tokens %v but got %v", expected, types)
+	}
+}
+
+// Without a key and a colon first, the root is any other value
+func TestRootValues(t *testing.T) {
+	testCases := []struct {
+		input string
+		event string
+	}{
+		{"hello world", `string "hello world"`},
+		{"'quoted'", `string "quoted"`},
+		{"\"a\" ", `string "a"`},
+		{"12", "number 12"},
+		{"'''\ntext\n'''", `string "text"`},
+		{"url http://x", `string "url http://x"`},
+	}
+
+	for _, tc := range testCases {
+		r := &recorder{}
+		if err := parser.ParseWithHandler(hjson(tc.input), r, parser.DefaultOptions()); err != nil {
+			t.Errorf("%q: expected successful parsing but got: %v", tc.input, err)
+			continue
+		}
+		if len(r.events) != 1 || r.events[0] != tc.event {
+			t.Errorf("%q: expected %s but got %v", tc.input, tc.event, r.events)
+		}
+	}
+}
+
+func TestRootWithoutBracesInvalid(t *testing.T) {
+	testCases := []struct {
+		input string
+		kind  parser.ErrorKind
+	}{
+		{"a: 1\n}", parser.KindUnexpectedCharacter},
+		{"a: 1\n]", parser.KindUnexpectedToken},
+		{"a: [1\n", parser.KindUnexpectedEOF},
+		{"a: {b: 1\n", parser.KindUnexpectedEOF},
+		{"a:", parser.KindUnexpectedEOF},
+		{"a: 1\nb", parser.KindUnexpectedEOF},
+	}
+
+	for _, tc := range testCases {
+		err := parser.Parse(hjson(tc.input))
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a *ParseError but got: %v", tc.input, err)
+			continue
+		}
+		if parseErr.Kind != tc.kind {
+			t.Errorf("%q: expected a %v error but got %v: %v", tc.input, tc.kind, parseErr.Kind, err)
+		}
+	}
+}
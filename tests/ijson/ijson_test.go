@@ -0,0 +1,129 @@
+package ijson
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+func ijson() parser.Options {
+	opts := parser.DefaultOptions()
+	opts.Profile = parser.ProfileIJSON
+	return opts
+}
+
+func TestValidDocuments(t *testing.T) {
+	for _, input := range []string{
+		`{}`,
+		`[]`,
+		`{"name": "ünïcödé 😀", "n": [0, -1, 1.5, 0.1, 1e20, 9007199254740991, -9007199254740991]}`,
+		`[{"a": 1}, {"a": 2}]`,
+		`["😀", "�"]`,
+	} {
+		if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), ijson()); err != nil {
+			t.Errorf("%s: expected successful parsing but got: %v", input, err)
+		}
+	}
+}
+
+func TestRejectedDocuments(t *testing.T) {
+	testCases := []struct {
+		input string
+		kind  parser.ErrorKind
+	}{
+		{`"scalar"`, parser.KindUnexpectedToken},
+		{`42`, parser.KindUnexpectedToken},
+		{`{"a": 1, "b": {}, "a": 2}`, parser.KindDuplicateKey},
+		{`["\ud800"]`, parser.KindLoneSurrogate},
+		{`["\udc00x"]`, parser.KindLoneSurrogate},
+		{"[\"\xff\"]", parser.KindInvalidUTF8},
+		{`["￿"]`, parser.KindProfile},
+		{`["﷐"]`, parser.KindProfile},
+		{`{"￾": 1}`, parser.KindProfile},
+		{"[\"\U0010FFFF\"]", parser.KindProfile},
+		{`[9007199254740992]`, parser.KindProfile},
+		{`[-9007199254740992]`, parser.KindProfile},
+		{`[123456789012345678901234567890]`, parser.KindProfile},
+		{`[0.30000000000000000001]`, parser.KindProfile},
+		{`[1e400]`, parser.KindProfile},
+		{`[1e-400]`, parser.KindProfile},
+	}
+
+	for _, tc := range testCases {
+		err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(tc.input)), ijson())
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a *ParseError but got: %v", tc.input, err)
+			continue
+		}
+		if parseErr.Kind != tc.kind {
+			t.Errorf("%s: expected a %v error but got %v: %v", tc.input, tc.kind, parseErr.Kind, err)
+		}
+	}
+}
+
+func TestProfileIsNamedInErrors(t *testing.T) {
+	err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`[9007199254740993]`)), ijson())
+	if err == nil || !strings.Contains(err.Error(), "I-JSON") {
+		t.Errorf("Expected the error to name I-JSON but got %v", err)
+	}
+}
+
+// The profile wins over options that would let its rules be broken
+func TestProfileOverridesOptions(t *testing.T) {
+	opts := ijson()
+	opts.DuplicateKeys = parser.DuplicateKeepLast
+	lenient := tokenizer.Options{LoneSurrogates: tokenizer.SurrogateReplace, InvalidUTF8: tokenizer.UTF8Replace}
+
+	for _, input := range []string{`{"a": 1, "a": 2}`, `["\ud800"]`, "[\"\xff\"]"} {
+		tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), lenient)
+		if err := parser.ParseWithOptions(tok, opts); err == nil {
+			t.Errorf("%s: expected unsuccessful parsing but was able to parse", input)
+		}
+	}
+}
+
+// The profile leaves the tokenizer as it found it
+func TestTokenizerOptionsRestored(t *testing.T) {
+	options := tokenizer.Options{Dialect: tokenizer.DialectJSONC, InvalidUTF8: tokenizer.UTF8Replace, LenientNumbers: true}
+	tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader("[1] // comment"), options)
+	if err := parser.ParseWithOptions(tok, ijson()); err == nil {
+		t.Error("Expected I-JSON to reject the comment")
+	}
+	if tok.Options() != options {
+		t.Errorf("Expected options %+v but got %+v", options, tok.Options())
+	}
+}
+
+func TestDecoderAndStreams(t *testing.T) {
+	d := parser.NewDecoderWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`[1, 9007199254740993]`)), ijson())
+	if _, err := d.Decode(); err == nil {
+		t.Error("Expected the decoder to apply the profile")
+	}
+
+	r := parser.NewDocumentReaderWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader("[1]\n2\n{\"a\":1,\"a\":1}\n")), parser.StreamLines, ijson())
+	var valid []bool
+	for {
+		err := r.Next(parser.NopHandler{})
+		if err != nil && err.Error() == "EOF" {
+			break
+		}
+		valid = append(valid, err == nil)
+	}
+	if len(valid) != 3 || !valid[0] || valid[1] || valid[2] {
+		t.Errorf("Expected only the first line to be valid but got %v", valid)
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	for _, profile := range []parser.Profile{parser.ProfileNone, parser.ProfileIJSON} {
+		if parsed, ok := parser.ParseProfile(profile.String()); !ok || parsed != profile {
+			t.Errorf("Expected profile %v to round-trip", profile)
+		}
+	}
+	if _, ok := parser.ParseProfile("strict"); ok {
+		t.Error("Expected an unknown profile name to be rejected")
+	}
+}
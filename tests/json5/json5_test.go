@@ -0,0 +1,397 @@
+package json5
+
+import (
+	"bytes"
+	"errors"
+	"json-parser/pkg/encoder"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"math"
+	"strings"
+	"testing"
+)
+
+func json5(input string) *tokenizer.Tokenizer {
+	return tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectJSON5})
+}
+
+// The example from https://json5.org
+const example = `// JSON5 example
+{
+  // comments
+  unquoted: 'and you can quote me on that',
+  singleQuotes: 'I can use "double quotes" here',
+  lineBreaks: "Look, Mom! \
+No \\n's!",
+  hexadecimal: 0xdecaf,
+  leadingDecimalPoint: .8675309, andTrailing: 8675309.,
+  positiveSign: +1,
+  trailingComma: 'in objects', andIn: ['arrays',],
+  "backwardsCompatible": "with JSON",
+}
+`
+
+func TestExample(t *testing.T) {
+	v, err := parser.ParseValue(json5(example))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+
+	expected := map[string]string{
+		"unquoted":            "and you can quote me on that",
+		"singleQuotes":        `I can use "double quotes" here`,
+		"lineBreaks":          `Look, Mom! No \n's!`,
+		"trailingComma":       "in objects",
+		"backwardsCompatible": "with JSON",
+	}
+	for key, want := range expected {
+		member, ok := v.Get(key)
+		if !ok {
+			t.Errorf("Expected a member %s", key)
+			continue
+		}
+		if s, _ := member.Str(); s != want {
+			t.Errorf("%s: expected %q but got %q", key, want, s)
+		}
+	}
+
+	numbers := map[string]string{
+		"hexadecimal":         "912559",
+		"leadingDecimalPoint": "0.8675309",
+		"andTrailing":         "8675309",
+		"positiveSign":        "1",
+	}
+	for key, want := range numbers {
+		member, _ := v.Get(key)
+		if n, ok := member.Number(); !ok || n.String() != want {
+			t.Errorf("%s: expected the number %s but got %v", key, want, member)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	testCases := []struct {
+		input string
+		value string
+	}{
+		{"0x1F", "31"},
+		{"0XdeadBEEF", "3735928559"},
+		{"-0x10", "-16"},
+		{"+0x10", "16"},
+		{"0x123456789abcdef0123", "5373003642731685151011"},
+		{".5", "0.5"},
+		{"-.5e1", "-0.5e1"},
+		{"5.", "5"},
+		{"+5.e-1", "5e-1"},
+		{"+1.25", "1.25"},
+		{"-0", "-0"},
+		{"12", "12"},
+		{"1.5E+3", "1.5E+3"},
+		{"Infinity", "Infinity"},
+		{"+Infinity", "Infinity"},
+		{"-Infinity", "-Infinity"},
+		{"NaN", "NaN"},
+		{"-NaN", "NaN"},
+	}
+
+	for _, tc := range testCases {
+		token, err := json5(tc.input).NextToken()
+		if err != nil {
+			t.Errorf("%s: expected a number but got: %v", tc.input, err)
+			continue
+		}
+		if token.Type != tokenizer.TokenNumber || token.Value != tc.value || token.Raw != tc.input {
+			t.Errorf("%s: expected the number %s but got %v %q (%q)", tc.input, tc.value, token.Type, token.Value, token.Raw)
+		}
+	}
+}
+
+func TestNonFinite(t *testing.T) {
+	v, err := parser.ParseValue(json5("[Infinity, -Infinity, NaN]"))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	for i, check := range []func(float64) bool{
+		func(f float64) bool { return math.IsInf(f, 1) },
+		func(f float64) bool { return math.IsInf(f, -1) },
+		math.IsNaN,
+	} {
+		n, _ := v.Index(i).Number()
+		f, err := n.Float64()
+		if err != nil || !check(f) {
+			t.Errorf("%s: expected the float64 it names but got %v, %v", n, f, err)
+		}
+		if n.IsFinite() || n.IsInteger() {
+			t.Errorf("%s: expected a non-finite number that is no integer", n)
+		}
+		if _, err := n.Int64(); err == nil {
+			t.Errorf("%s: expected no int64", n)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	testCases := []struct {
+		input string
+		value string
+	}{
+		{`'single'`, "single"},
+		{`'it\'s'`, "it's"},
+		{`'"'`, `"`},
+		{`"'"`, "'"},
+		{`'\x41\x7a'`, "Az"},
+		{`'\0'`, "\x00"},
+		{`'\v'`, "\v"},
+		{`'\a\c\d\%'`, "acd%"},
+		{"'one\\\ntwo'", "onetwo"},
+		{"'one\\\r\ntwo'", "onetwo"},
+		{"'one\\\rtwo'", "onetwo"},
+		{"'one\\\u2028two'", "onetwo"},
+		{"'tab\there'", "tab\there"},
+		{"'line\u2028separator'", "line\u2028separator"},
+		{`'\u00e9\😀'`, "é😀"},
+	}
+
+	for _, tc := range testCases {
+		token, err := json5(tc.input).NextToken()
+		if err != nil {
+			t.Errorf("%s: expected a string but got: %v", tc.input, err)
+			continue
+		}
+		if token.Type != tokenizer.TokenString || token.Value != tc.value {
+			t.Errorf("%s: expected the string %q but got %v %q", tc.input, tc.value, token.Type, token.Value)
+		}
+	}
+}
+
+func TestIdentifierKeys(t *testing.T) {
+	input := `{$: 1, _a1: 2, café: 3, \u0061b: 4, null: 5, true: 6, Infinity: 7, NaN: 8, a\u200Db: 9}`
+	v, err := parser.ParseValue(json5(input))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	expected := []string{"$", "_a1", "café", "ab", "null", "true", "Infinity", "NaN", "a\u200Db"}
+	keys := v.Keys()
+	if strings.Join(keys, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected keys %q but got %q", expected, keys)
+	}
+}
+
+func TestWhitespace(t *testing.T) {
+	input := "\ufeff{\va\f:\u00a01,\u2028b:\u20032\u2029}"
+	if err := parser.Parse(json5(input)); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	testCases := []struct {
+		input string
+		kind  parser.ErrorKind
+	}{
+		{`[foo]`, parser.KindUnexpectedToken},
+		{`{a b: 1}`, parser.KindUnexpectedToken},
+		{`{1: 1}`, parser.KindUnexpectedToken},
+		{`{-Infinity: 1}`, parser.KindUnexpectedToken},
+		{`{a\u0020b: 1}`, parser.KindInvalidEscape},
+		{`{\x61: 1}`, parser.KindInvalidEscape},
+		{`{1a: 1}`, parser.KindUnexpectedToken},
+		{`['\x4']`, parser.KindInvalidEscape},
+		{`['\01']`, parser.KindInvalidEscape},
+		{`['\1']`, parser.KindInvalidEscape},
+		{"['line\nbreak']", parser.KindControlCharacter},
+		{`['open]`, parser.KindUnterminatedString},
+		{`[01]`, parser.KindInvalidNumber},
+		{`[.]`, parser.KindInvalidNumber},
+		{`[+]`, parser.KindInvalidNumber},
+		{`[0x]`, parser.KindInvalidNumber},
+		{`[1e]`, parser.KindInvalidNumber},
+		{`[-Infinit]`, parser.KindInvalidLiteral},
+		{`[+nan]`, parser.KindInvalidNumber},
+		{`[1,,]`, parser.KindUnexpectedToken},
+		{"[\u00a7]", parser.KindUnexpectedCharacter},
+		{`[1 /* open`, parser.KindUnterminatedComment},
+	}
+
+	for _, tc := range testCases {
+		err := parser.Parse(json5(tc.input))
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a *ParseError but got: %v", tc.input, err)
+			continue
+		}
+		if parseErr.Kind != tc.kind {
+			t.Errorf("%s: expected a %v error but got %v: %v", tc.input, tc.kind, parseErr.Kind, err)
+		}
+	}
+}
+
+// None of JSON5's additions leak into the other dialects
+func TestStrictJSON(t *testing.T) {
+	for _, input := range []string{`{a: 1}`, `['a']`, `[0x1]`, `[.5]`, `[+1]`, `[Infinity]`, `[NaN]`, `["\x41"]`, "[\u00a01]"} {
+		for _, dialect := range []tokenizer.Dialect{tokenizer.DialectJSON, tokenizer.DialectJSONC} {
+			tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: dialect})
+			if err := parser.Parse(tok); err == nil {
+				t.Errorf("%s: expected %v to reject it", input, dialect)
+			}
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	input := "{café: 'x\\\ny', n: -.5, h: 0xFF, i: -Infinity,}"
+	for size := 1; size <= len(input); size++ {
+		tok := tokenizer.NewFeedTokenizerWithOptions(tokenizer.Options{Dialect: tokenizer.DialectJSON5})
+		var got bytes.Buffer
+		p := parser.NewFeedParser(tok, encoder.NewEncoderWithOptions(&got, encoder.Options{Format: encoder.FormatJSON5}))
+		data := []byte(input)
+		for len(data) > 0 {
+			n := min(size, len(data))
+			if err := p.Feed(data[:n]); err != nil {
+				t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+			}
+			data = data[n:]
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+		}
+		if got.String() != "{'café':'xy',n:-0.5,h:255,i:-Infinity}\n" {
+			t.Errorf("chunks of %d: got %s", size, got.String())
+		}
+	}
+}
+
+func TestWriteJSON5(t *testing.T) {
+	v, err := parser.ParseValue(json5(example))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := encoder.NewEncoderWithOptions(&out, encoder.Options{Format: encoder.FormatJSON5}).Encode(v); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	expected := `{unquoted:'and you can quote me on that',singleQuotes:'I can use "double quotes" here',lineBreaks:"Look, Mom! No \\n's!",hexadecimal:912559,leadingDecimalPoint:0.8675309,andTrailing:8675309,positiveSign:1,trailingComma:'in objects',andIn:['arrays'],backwardsCompatible:'with JSON'}` + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected %s but got %s", expected, out.String())
+	}
+
+	// What is written reads back as the same document
+	again, err := parser.ParseValue(json5(out.String()))
+	if err != nil {
+		t.Fatalf("Expected the output to parse but got: %v", err)
+	}
+	var twice bytes.Buffer
+	encoder.NewEncoderWithOptions(&twice, encoder.Options{Format: encoder.FormatJSON5}).Encode(again)
+	if twice.String() != out.String() {
+		t.Errorf("Expected %s but got %s", out.String(), twice.String())
+	}
+}
+
+func TestWriteStrings(t *testing.T) {
+	testCases := []struct {
+		s     string
+		json  string
+		json5 string
+	}{
+		{"plain", `"plain"`, `'plain'`},
+		{`it's`, `"it's"`, `"it's"`},
+		{`"'`, `"\"'"`, `'"\''`},
+		{"a\\b", `"a\\b"`, `'a\\b'`},
+		{"\n\t\x01\x7f", `"\n\t\u0001` + "\x7f\"", `'\n\t\x01` + "\x7f'"},
+		{"é😀", `"é😀"`, `'é😀'`},
+		{"\u2028\u2029", `"\u2028\u2029"`, `'\u2028\u2029'`},
+		{"bad\xffutf8", `"bad\ufffdutf8"`, `'bad\ufffdutf8'`},
+	}
+
+	for _, tc := range testCases {
+		var json, json5 bytes.Buffer
+		encoder.NewEncoder(&json).String(tc.s)
+		encoder.NewEncoderWithOptions(&json5, encoder.Options{Format: encoder.FormatJSON5}).String(tc.s)
+		if json.String() != tc.json+"\n" {
+			t.Errorf("%q: expected JSON %s but got %s", tc.s, tc.json, json.String())
+		}
+		if json5.String() != tc.json5+"\n" {
+			t.Errorf("%q: expected JSON5 %s but got %s", tc.s, tc.json5, json5.String())
+		}
+	}
+}
+
+func TestWriteIndented(t *testing.T) {
+	v, err := parser.ParseValue(json5(`{a: [1, {}, []], 'b c': {d: null}, e: true}`))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	var out bytes.Buffer
+	encoder.NewEncoderWithOptions(&out, encoder.Options{Format: encoder.FormatJSON5, Indent: "  "}).Encode(v)
+	expected := `{
+  a: [
+    1,
+    {},
+    []
+  ],
+  'b c': {
+    d: null
+  },
+  e: true
+}
+`
+	if out.String() != expected {
+		t.Errorf("Expected %s but got %s", expected, out.String())
+	}
+}
+
+// Infinity and NaN only exist in JSON5, and a failed document writes nothing
+func TestWriteNonFiniteAsJSON(t *testing.T) {
+	v, err := parser.ParseValue(json5(`{a: [1, NaN]}`))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	var out bytes.Buffer
+	enc := encoder.NewEncoder(&out)
+	if err := enc.Encode(v); !errors.Is(err, encoder.ErrNonFinite) {
+		t.Errorf("Expected ErrNonFinite but got: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written but got %s", out.String())
+	}
+	if err := enc.Encode(parser.NewArray(parser.NewNumber("1"))); err != nil || out.String() != "[1]\n" {
+		t.Errorf("Expected the next document to be written but got %s, %v", out.String(), err)
+	}
+}
+
+func TestFormatNames(t *testing.T) {
+	for _, format := range []encoder.Format{encoder.FormatJSON, encoder.FormatJSON5} {
+		if parsed, ok := encoder.ParseFormat(format.String()); !ok || parsed != format {
+			t.Errorf("Expected format %v to round-trip", format)
+		}
+	}
+	if dialect, ok := tokenizer.ParseDialect("json5"); !ok || dialect != tokenizer.DialectJSON5 {
+		t.Error("Expected the json5 dialect to be known")
+	}
+}
+
+// A document that fails partway leaves nothing behind in the encoder
+func TestReuseAfterFailedParse(t *testing.T) {
+	var out bytes.Buffer
+	enc := encoder.NewEncoder(&out)
+	if err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(`{"a": [1, x`)), enc, parser.DefaultOptions()); err == nil {
+		t.Fatal("Expected unsuccessful parsing but was able to parse")
+	}
+	if err := parser.ParseWithHandler(tokenizer.NewTokenizerFromReader(strings.NewReader(`{"b": 2}`)), enc, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	if out.String() != "{\"b\":2}\n" {
+		t.Errorf("Expected the second document but got %q", out.String())
+	}
+
+	out.Reset()
+	input := "{\"a\": [1\n{\"b\": 2}\n[3]\n"
+	r := parser.NewDocumentReader(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), parser.StreamLines)
+	for range 3 {
+		r.Next(enc)
+	}
+	if out.String() != "{\"b\":2}\n[3]\n" {
+		t.Errorf("Expected the valid lines but got %q", out.String())
+	}
+}
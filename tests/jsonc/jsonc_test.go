@@ -0,0 +1,254 @@
+package jsonc
+
+import (
+	"errors"
+	"io"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func jsonc(input string) *tokenizer.Tokenizer {
+	return tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectJSONC})
+}
+
+func TestValidDocuments(t *testing.T) {
+	for _, input := range []string{
+		"// settings\n{\"a\": 1}",
+		"{\"a\": 1} // trailing",
+		"{\"a\": 1} /* trailing */",
+		"{/* empty */}",
+		"[1, /* two */ 2, 3 // three\n]",
+		"{\"a\" /* key */ : /* value */ \"//not a comment\"}",
+		"/**/[/***/]/* multi\nline */",
+		"{\"a\": [1, 2,], \"b\": {\"c\": true,},}",
+		"[[],]",
+		"// only a line comment before the value\r\n42",
+		"[1]// comment at the end of input",
+	} {
+		if err := parser.Parse(jsonc(input)); err != nil {
+			t.Errorf("%q: expected successful parsing but got: %v", input, err)
+		}
+	}
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	testCases := []struct {
+		input string
+		kind  parser.ErrorKind
+	}{
+		{`[1, /* open`, parser.KindUnterminatedComment},
+		{`[1, /* open *`, parser.KindUnterminatedComment},
+		{`[1 / 2]`, parser.KindUnexpectedCharacter},
+		{`[1,,]`, parser.KindUnexpectedToken},
+		{`[,]`, parser.KindUnexpectedToken},
+		{`{,}`, parser.KindUnexpectedToken},
+		{`{"a": 1,,}`, parser.KindUnexpectedToken},
+		{`{"a",}`, parser.KindUnexpectedToken},
+		{`1,`, parser.KindUnexpectedToken},
+		{`// nothing but a comment`, parser.KindUnexpectedEOF},
+	}
+
+	for _, tc := range testCases {
+		err := parser.Parse(jsonc(tc.input))
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a *ParseError but got: %v", tc.input, err)
+			continue
+		}
+		if parseErr.Kind != tc.kind {
+			t.Errorf("%q: expected a %v error but got %v: %v", tc.input, tc.kind, parseErr.Kind, err)
+		}
+	}
+}
+
+// Strict JSON stays the default
+func TestStrictJSON(t *testing.T) {
+	for _, input := range []string{"// comment\n[]", "[/**/]", "[1,]", `{"a": 1,}`} {
+		if err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(input))); err == nil {
+			t.Errorf("%q: expected unsuccessful parsing but was able to parse", input)
+		}
+	}
+}
+
+func TestTrailingCommasWithoutComments(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.TrailingCommas = true
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`{"a": [1,],}`)), opts); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`[1, /**/]`)), opts); err == nil {
+		t.Error("Expected comments to stay invalid JSON")
+	}
+}
+
+func TestCommentTokens(t *testing.T) {
+	input := "// one\n[1, /* two */ 2]"
+	tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectJSONC, EmitComments: true})
+
+	var types []tokenizer.TokenType
+	var comments []tokenizer.Token
+	for {
+		token, err := tok.NextToken()
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		types = append(types, token.Type)
+		if token.Type == tokenizer.TokenComment {
+			comments = append(comments, token)
+		}
+		if token.Type == tokenizer.TokenEOF {
+			break
+		}
+	}
+
+	expected := []tokenizer.TokenType{
+		tokenizer.TokenComment, tokenizer.TokenLeftSquare, tokenizer.TokenNumber, tokenizer.TokenComma,
+		tokenizer.TokenComment, tokenizer.TokenNumber, tokenizer.TokenRightSquare, tokenizer.TokenEOF,
+	}
+	if !slices.Equal(types, expected) {
+		t.Fatalf("Expected tokens %v but got %v", expected, types)
+	}
+	if comments[0].Value != " one" || comments[0].Raw != "// one" {
+		t.Errorf("Expected a line comment \" one\" but got %q (%q)", comments[0].Value, comments[0].Raw)
+	}
+	if comments[1].Value != " two " || comments[1].Raw != "/* two */" {
+		t.Errorf("Expected a block comment \" two \" but got %q (%q)", comments[1].Value, comments[1].Raw)
+	}
+	if comments[1].Start.Line != 2 || comments[1].Start.Column != 5 || comments[1].End.Column != 14 {
+		t.Errorf("Expected the block comment at line 2, columns 5 to 14 but got %v to %v", comments[1].Start, comments[1].End)
+	}
+}
+
+type commentRecorder struct {
+	parser.NopHandler
+	events []string
+}
+
+func (r *commentRecorder) Comment(text string) error {
+	r.events = append(r.events, "comment"+text)
+	return nil
+}
+
+func (r *commentRecorder) Number(literal string) error {
+	r.events = append(r.events, literal)
+	return nil
+}
+
+func TestCommentHandler(t *testing.T) {
+	input := "/*a*/[1,//b\n2]//c"
+	tok := tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectJSONC, EmitComments: true})
+	r := &commentRecorder{}
+	if err := parser.ParseWithHandler(tok, r, parser.DefaultOptions()); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	expected := []string{"commenta", "1", "commentb", "2", "commentc"}
+	if !slices.Equal(r.events, expected) {
+		t.Errorf("Expected events %v but got %v", expected, r.events)
+	}
+
+	// A handler without Comment does not see them
+	tok = tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{Dialect: tokenizer.DialectJSONC, EmitComments: true})
+	if err := parser.ParseWithHandler(tok, parser.NopHandler{}, parser.DefaultOptions()); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	d := parser.NewDecoder(jsonc("[{\"a\": 1, /* b */ \"b\": [2,],}, 3,] // done"))
+	var kinds []parser.EventKind
+	for {
+		event, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		kinds = append(kinds, event.Kind)
+	}
+	expected := []parser.EventKind{
+		parser.EventStartArray, parser.EventStartObject, parser.EventKey, parser.EventNumber,
+		parser.EventKey, parser.EventStartArray, parser.EventNumber, parser.EventEndArray,
+		parser.EventEndObject, parser.EventNumber, parser.EventEndArray,
+	}
+	if !slices.Equal(kinds, expected) {
+		t.Errorf("Expected events %v but got %v", expected, kinds)
+	}
+
+	d = parser.NewDecoder(jsonc("[1,]"))
+	d.Token()
+	var count int
+	for d.More() {
+		if _, err := d.Decode(); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 element but got %d", count)
+	}
+}
+
+// A line comment ends before the line feed that ends the document
+func TestLines(t *testing.T) {
+	input := "[1] // first\n{\"a\": 2,} /* second */\n[,]\n"
+	r := parser.NewDocumentReader(jsonc(input), parser.StreamLines)
+	var valid []bool
+	for {
+		err := r.Next(parser.NopHandler{})
+		if err == io.EOF {
+			break
+		}
+		valid = append(valid, err == nil)
+	}
+	if !slices.Equal(valid, []bool{true, true, false}) {
+		t.Errorf("Expected the first two lines to be valid but got %v", valid)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	input := "/* a */ [1, // b\n 2,]"
+	for size := 1; size <= len(input); size++ {
+		tok := tokenizer.NewFeedTokenizerWithOptions(tokenizer.Options{Dialect: tokenizer.DialectJSONC})
+		r := &commentRecorder{}
+		p := parser.NewFeedParser(tok, r)
+		data := []byte(input)
+		for len(data) > 0 {
+			n := min(size, len(data))
+			if err := p.Feed(data[:n]); err != nil {
+				t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+			}
+			data = data[n:]
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+		}
+		if !slices.Equal(r.events, []string{"1", "2"}) {
+			t.Errorf("chunks of %d: expected numbers [1 2] but got %v", size, r.events)
+		}
+	}
+}
+
+func TestIJSONIsStrict(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.Profile = parser.ProfileIJSON
+	for _, input := range []string{"[1,]", "[1] // comment"} {
+		if err := parser.ParseWithOptions(jsonc(input), opts); err == nil {
+			t.Errorf("%q: expected unsuccessful parsing but was able to parse", input)
+		}
+	}
+}
+
+func TestDialectNames(t *testing.T) {
+	for _, dialect := range []tokenizer.Dialect{tokenizer.DialectJSON, tokenizer.DialectJSONC} {
+		if parsed, ok := tokenizer.ParseDialect(dialect.String()); !ok || parsed != dialect {
+			t.Errorf("Expected dialect %v to round-trip", dialect)
+		}
+	}
+	if _, ok := tokenizer.ParseDialect("yaml"); ok {
+		t.Error("Expected an unknown dialect name to be rejected")
+	}
+}
@@ -0,0 +1,101 @@
+package limits
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		options parser.Options
+		kind    parser.ErrorKind
+	}{
+		{"Depth", `[[[1]]]`, parser.Options{MaxDepth: 2}, parser.KindNestingLimit},
+		{"Bytes", `{"key": "value"}`, parser.Options{MaxBytes: 10}, parser.KindDocumentTooLarge},
+		{"TrailingWhitespace", "[1]     ", parser.Options{MaxBytes: 5}, parser.KindDocumentTooLarge},
+		{"StringLength", `["abcdef"]`, parser.Options{MaxStringLength: 5}, parser.KindStringTooLong},
+		{"KeyLength", `{"abcdef": 1}`, parser.Options{MaxStringLength: 5}, parser.KindStringTooLong},
+		{"NumberLength", `[123456]`, parser.Options{MaxNumberLength: 5}, parser.KindNumberTooLong},
+		{"FractionLength", `[1.23456e7]`, parser.Options{MaxNumberLength: 5}, parser.KindNumberTooLong},
+		{"ArrayMembers", `[1, 2, 3]`, parser.Options{MaxMembers: 2}, parser.KindTooManyMembers},
+		{"ObjectMembers", `{"a": 1, "b": [1], "c": 3}`, parser.Options{MaxMembers: 2}, parser.KindTooManyMembers},
+		{"Tokens", `[1, 2, 3]`, parser.Options{MaxTokens: 6}, parser.KindTooManyTokens},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(tc.input)), tc.options)
+			var parseErr *parser.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError but got: %v", err)
+			}
+			if parseErr.Kind != tc.kind {
+				t.Errorf("Expected error kind %q but got %q (%v)", tc.kind, parseErr.Kind, err)
+			}
+		})
+	}
+}
+
+func TestWithinLimits(t *testing.T) {
+	input := `{"a": [1, 2], "bcdef": 12345}`
+	options := parser.Options{
+		MaxDepth:        2,
+		MaxBytes:        int64(len(input)),
+		MaxStringLength: 5,
+		MaxNumberLength: 5,
+		MaxMembers:      2,
+		MaxTokens:       13,
+	}
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromBytes([]byte(input)), options); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+}
+
+// endlessString is a reader producing an unterminated string that never ends
+type endlessString struct {
+	started bool
+}
+
+func (r *endlessString) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	if !r.started {
+		r.started = true
+		p[0] = '"'
+	}
+	return len(p), nil
+}
+
+func TestMaxBytesStopsReading(t *testing.T) {
+	err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(&endlessString{}), parser.Options{MaxBytes: 1 << 20})
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindDocumentTooLarge {
+		t.Errorf("Expected a document too large error but got: %v", err)
+	}
+}
+
+// Without a depth limit nesting is bounded by memory, not by the call stack
+func TestUnlimitedDepth(t *testing.T) {
+	const depth = 2000000
+	nested := strings.Repeat("[", depth) + strings.Repeat("]", depth)
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(nested)), parser.Options{}); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+
+	objects := strings.Repeat(`{"a":`, depth) + "1" + strings.Repeat("}", depth)
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(objects)), parser.Options{}); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+
+	err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(strings.Repeat("[", depth))), parser.Options{})
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != parser.KindUnexpectedEOF {
+		t.Errorf("Expected an unexpected end of input but got: %v", err)
+	}
+}
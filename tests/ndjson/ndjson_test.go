@@ -0,0 +1,139 @@
+package ndjson
+
+import (
+	"errors"
+	"io"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// result is the outcome of reading one document from a stream
+type result struct {
+	line  int
+	valid bool
+}
+
+func readAll(t *testing.T, r *parser.DocumentReader) []result {
+	t.Helper()
+	var results []result
+	for {
+		err := r.Next(parser.NopHandler{})
+		if err == io.EOF {
+			return results
+		}
+		var parseErr *parser.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			t.Fatalf("Expected a *ParseError but got: %v", err)
+		}
+		if err != nil && parseErr.Pos.Line != r.Start().Line {
+			t.Errorf("Expected the error on line %d but got %v", r.Start().Line, parseErr.Pos)
+		}
+		results = append(results, result{r.Start().Line, err == nil})
+	}
+}
+
+func newReader(input string) *parser.DocumentReader {
+	return parser.NewDocumentReader(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), parser.StreamLines)
+}
+
+func TestValues(t *testing.T) {
+	r := newReader("{\"id\": 1}\n[2]\n\"three\"\n4\n")
+	var kinds []parser.ValueKind
+	for {
+		value, err := r.NextValue()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected successful parsing but got: %v", err)
+		}
+		kinds = append(kinds, value.Kind())
+	}
+	expected := []parser.ValueKind{parser.ObjectValue, parser.ArrayValue, parser.StringValue, parser.NumberValue}
+	if !slices.Equal(kinds, expected) {
+		t.Errorf("Expected %v but got %v", expected, kinds)
+	}
+}
+
+func TestInvalidLinesDoNotStopTheStream(t *testing.T) {
+	input := strings.Join([]string{
+		`{"ok": 1}`,
+		`[1, 2`,
+		`"a" "b"`,
+		`{"s": "unterminated`,
+		`{"x": 1x}`,
+		`{"a":`,
+		`}`,
+		`  {"ok": 2}  `,
+		`nul`,
+		`[3]`,
+	}, "\n")
+	expected := []result{
+		{1, true}, {2, false}, {3, false}, {4, false}, {5, false},
+		{6, false}, {7, false}, {8, true}, {9, false}, {10, true},
+	}
+
+	if results := readAll(t, newReader(input)); !slices.Equal(results, expected) {
+		t.Errorf("Expected %v but got %v", expected, results)
+	}
+}
+
+func TestBlankLines(t *testing.T) {
+	results := readAll(t, newReader("\n\n1\r\n  \n\t\n2\n\n"))
+	if expected := []result{{3, true}, {6, true}}; !slices.Equal(results, expected) {
+		t.Errorf("Expected %v but got %v", expected, results)
+	}
+	if results := readAll(t, newReader("")); len(results) != 0 {
+		t.Errorf("Expected no documents but got %v", results)
+	}
+}
+
+func TestLastLineWithoutNewline(t *testing.T) {
+	results := readAll(t, newReader("1\n[2, 3"))
+	if expected := []result{{1, true}, {2, false}}; !slices.Equal(results, expected) {
+		t.Errorf("Expected %v but got %v", expected, results)
+	}
+}
+
+func TestOptionsApplyPerDocument(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.MaxTokens = 3
+	opts.DuplicateKeys = parser.DuplicateReject
+	r := parser.NewDocumentReaderWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader("[1]\n[2]\n[1, 2]\n{\"a\": 1, \"a\": 2}\n[3]\n")), parser.StreamLines, opts)
+
+	results := readAll(t, r)
+	expected := []result{{1, true}, {2, true}, {3, false}, {4, false}, {5, true}}
+	if !slices.Equal(results, expected) {
+		t.Errorf("Expected %v but got %v", expected, results)
+	}
+}
+
+func TestReadErrorEndsStream(t *testing.T) {
+	readErr := errors.New("connection reset")
+	r := parser.NewDocumentReader(tokenizer.NewTokenizerFromReader(io.MultiReader(strings.NewReader("1\n"), iotest.ErrReader(readErr))), parser.StreamLines)
+
+	if err := r.Next(parser.NopHandler{}); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	for range 2 {
+		if err := r.Next(parser.NopHandler{}); !errors.Is(err, readErr) {
+			t.Fatalf("Expected the read error but got: %v", err)
+		}
+	}
+}
+
+func TestNewlineInsideValue(t *testing.T) {
+	r := newReader("{\"a\":\n1}\n")
+	err := r.Next(parser.NopHandler{})
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *ParseError but got: %v", err)
+	}
+	if parseErr.Token.Type != tokenizer.TokenNewline {
+		t.Errorf("Expected the end of line to be the offending token but got %v", parseErr.Token.Type)
+	}
+}
@@ -0,0 +1,192 @@
+package nonfinite
+
+import (
+	"bytes"
+	"errors"
+	"json-parser/pkg/encoder"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"math"
+	"strings"
+	"testing"
+)
+
+func lenient(input string) *tokenizer.Tokenizer {
+	return tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), tokenizer.Options{LenientNumbers: true})
+}
+
+// As Python's json.dumps writes them
+const document = `{"loss": NaN, "max": Infinity, "min": -Infinity, "step": 1}`
+
+func TestTokens(t *testing.T) {
+	testCases := []struct {
+		input string
+		value string
+	}{
+		{"NaN", "NaN"},
+		{"Infinity", "Infinity"},
+		{"-Infinity", "-Infinity"},
+		{"-1", "-1"},
+		{"0.5", "0.5"},
+	}
+
+	for _, tc := range testCases {
+		token, err := lenient(tc.input).NextToken()
+		if err != nil {
+			t.Errorf("%s: expected a number but got: %v", tc.input, err)
+			continue
+		}
+		if token.Type != tokenizer.TokenNumber || token.Value != tc.value || token.Raw != tc.input {
+			t.Errorf("%s: expected number %s but got %v %s", tc.input, tc.value, token.Type, token.Value)
+		}
+	}
+}
+
+func TestInvalidLiterals(t *testing.T) {
+	for _, input := range []string{"nan", "NAN", "inf", "Inf", "-Inf", "+Infinity", "-NaN", "Infinite", "- Infinity"} {
+		if err := parser.Parse(lenient("[" + input + "]")); err == nil {
+			t.Errorf("%s: expected unsuccessful parsing but was able to parse", input)
+		}
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	v, err := parser.ParseValue(lenient(document))
+	if err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	checks := map[string]func(float64) bool{
+		"loss": math.IsNaN,
+		"max":  func(f float64) bool { return math.IsInf(f, 1) },
+		"min":  func(f float64) bool { return math.IsInf(f, -1) },
+	}
+	for key, check := range checks {
+		member, _ := v.Get(key)
+		n, _ := member.Number()
+		f, err := n.Float64()
+		if err != nil || !check(f) {
+			t.Errorf("%s: expected the float64 %s names but got %v, %v", key, n, f, err)
+		}
+		if n.IsFinite() {
+			t.Errorf("%s: expected %s not to be finite", key, n)
+		}
+		if f, ok := member.Float64(); !ok || !check(f) {
+			t.Errorf("%s: expected Value.Float64 to convert %s but got %v, %v", key, n, f, ok)
+		}
+	}
+}
+
+func TestNewFloat(t *testing.T) {
+	testCases := []struct {
+		f       float64
+		literal string
+	}{
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "Infinity"},
+		{math.Inf(-1), "-Infinity"},
+		{0.1, "0.1"},
+		{-2, "-2"},
+		{1e21, "1e+21"},
+	}
+
+	for _, tc := range testCases {
+		n, ok := parser.NewFloat(tc.f).Number()
+		if !ok || string(n) != tc.literal {
+			t.Errorf("%v: expected %s but got %s", tc.f, tc.literal, n)
+		}
+	}
+}
+
+// Without the option the literals stay invalid
+func TestStrictJSON(t *testing.T) {
+	for _, input := range []string{"[NaN]", "[Infinity]", "[-Infinity]"} {
+		err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(input)))
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a *ParseError but got: %v", input, err)
+		}
+	}
+
+	// I-JSON only allows numbers a double holds exactly
+	opts := parser.DefaultOptions()
+	opts.Profile = parser.ProfileIJSON
+	if err := parser.ParseWithOptions(lenient(document), opts); err == nil {
+		t.Error("Expected I-JSON to reject NaN and Infinity")
+	}
+}
+
+func TestFeed(t *testing.T) {
+	for size := 1; size <= len(document); size++ {
+		tok := tokenizer.NewFeedTokenizerWithOptions(tokenizer.Options{LenientNumbers: true})
+		var got bytes.Buffer
+		p := parser.NewFeedParser(tok, encoder.NewEncoderWithOptions(&got, encoder.Options{NonFinite: encoder.NonFiniteLiteral}))
+		data := []byte(document)
+		for len(data) > 0 {
+			n := min(size, len(data))
+			if err := p.Feed(data[:n]); err != nil {
+				t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+			}
+			data = data[n:]
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("chunks of %d: expected no error but got: %v", size, err)
+		}
+		if got.String() != `{"loss":NaN,"max":Infinity,"min":-Infinity,"step":1}`+"\n" {
+			t.Errorf("chunks of %d: got %s", size, got.String())
+		}
+	}
+}
+
+func TestEncoderPolicies(t *testing.T) {
+	testCases := []struct {
+		opts     encoder.Options
+		expected string
+	}{
+		{encoder.Options{NonFinite: encoder.NonFiniteNull}, `{"loss":null,"max":null,"min":null,"step":1}` + "\n"},
+		{encoder.Options{NonFinite: encoder.NonFiniteLiteral}, `{"loss":NaN,"max":Infinity,"min":-Infinity,"step":1}` + "\n"},
+		{encoder.Options{Format: encoder.FormatJSON5, NonFinite: encoder.NonFiniteNull}, `{loss:NaN,max:Infinity,min:-Infinity,step:1}` + "\n"},
+	}
+
+	for _, tc := range testCases {
+		var out bytes.Buffer
+		if err := parser.ParseWithHandler(lenient(document), encoder.NewEncoderWithOptions(&out, tc.opts), parser.DefaultOptions()); err != nil {
+			t.Errorf("%v: expected successful encoding but got: %v", tc.opts.NonFinite, err)
+			continue
+		}
+		if out.String() != tc.expected {
+			t.Errorf("%v: expected %s but got %s", tc.opts.NonFinite, tc.expected, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	err := parser.ParseWithHandler(lenient(document), encoder.NewEncoder(&out), parser.DefaultOptions())
+	if !errors.Is(err, encoder.ErrNonFinite) {
+		t.Errorf("Expected ErrNonFinite but got: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written but got %s", out.String())
+	}
+}
+
+// Null in an array or as a member keeps the separators right
+func TestNullInsideIndented(t *testing.T) {
+	var out bytes.Buffer
+	enc := encoder.NewEncoderWithOptions(&out, encoder.Options{Indent: "  ", NonFinite: encoder.NonFiniteNull})
+	if err := enc.Encode(parser.NewArray(parser.NewFloat(math.NaN()), parser.NewFloat(1.5))); err != nil {
+		t.Fatalf("Expected successful encoding but got: %v", err)
+	}
+	if out.String() != "[\n  null,\n  1.5\n]\n" {
+		t.Errorf("Expected an indented array but got %s", out.String())
+	}
+}
+
+func TestPolicyNames(t *testing.T) {
+	for _, policy := range []encoder.NonFinitePolicy{encoder.NonFiniteError, encoder.NonFiniteNull, encoder.NonFiniteLiteral} {
+		if parsed, ok := encoder.ParseNonFinitePolicy(policy.String()); !ok || parsed != policy {
+			t.Errorf("Expected policy %v to round-trip", policy)
+		}
+	}
+	if _, ok := encoder.ParseNonFinitePolicy("zero"); ok {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
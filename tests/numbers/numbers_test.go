@@ -0,0 +1,249 @@
+package numbers
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestInt64(t *testing.T) {
+	testCases := []struct {
+		literal  string
+		expected int64
+		err      error
+	}{
+		{"0", 0, nil},
+		{"-0", 0, nil},
+		{"42", 42, nil},
+		{"-9223372036854775808", math.MinInt64, nil},
+		{"9223372036854775807", math.MaxInt64, nil},
+		{"9223372036854775808", 0, parser.ErrOverflow},
+		{"-9223372036854775809", 0, parser.ErrOverflow},
+		{"1e3", 1000, nil},
+		{"1.0", 1, nil},
+		{"12.300e1", 123, nil},
+		{"1e19", 0, parser.ErrOverflow},
+		{"1.5", 0, parser.ErrNotInteger},
+		{"1e-1", 0, parser.ErrNotInteger},
+		{"0.0", 0, nil},
+		{"1e999999999", 0, parser.ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		i, err := parser.Number(tc.literal).Int64()
+		if !errors.Is(err, tc.err) || (err == nil && i != tc.expected) {
+			t.Errorf("%s: expected %d, %v but got %d, %v", tc.literal, tc.expected, tc.err, i, err)
+		}
+	}
+}
+
+func TestUint64(t *testing.T) {
+	testCases := []struct {
+		literal  string
+		expected uint64
+		err      error
+	}{
+		{"18446744073709551615", math.MaxUint64, nil},
+		{"18446744073709551616", 0, parser.ErrOverflow},
+		{"-1", 0, parser.ErrOverflow},
+		{"-0", 0, nil},
+		{"-0.0e5", 0, nil},
+		{"1.8446744073709551615e19", math.MaxUint64, nil},
+		{"0.5", 0, parser.ErrNotInteger},
+	}
+
+	for _, tc := range testCases {
+		u, err := parser.Number(tc.literal).Uint64()
+		if !errors.Is(err, tc.err) || (err == nil && u != tc.expected) {
+			t.Errorf("%s: expected %d, %v but got %d, %v", tc.literal, tc.expected, tc.err, u, err)
+		}
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	testCases := []struct {
+		literal  string
+		expected float64
+		err      error
+	}{
+		{"0.1", 0.1, nil},
+		{"-1.5e3", -1500, nil},
+		{"1E400", math.Inf(1), parser.ErrOverflow},
+		{"-1e400", math.Inf(-1), parser.ErrOverflow},
+		{"9007199254740992", 9007199254740992, nil},
+		{"9007199254740993", 9007199254740992, parser.ErrPrecision},
+		{"0.30000000000000000001", 0.3, parser.ErrPrecision},
+		{"1e-400", 0, parser.ErrPrecision},
+		{"123456789012345678", 123456789012345680, parser.ErrPrecision},
+		{"1.000000000000000000", 1, nil},
+		{"-0", math.Copysign(0, -1), nil},
+	}
+
+	for _, tc := range testCases {
+		f, err := parser.Number(tc.literal).Float64()
+		if !errors.Is(err, tc.err) || f != tc.expected {
+			t.Errorf("%s: expected %v, %v but got %v, %v", tc.literal, tc.expected, tc.err, f, err)
+		}
+	}
+}
+
+func TestBigInt(t *testing.T) {
+	huge := "123456789012345678901234567890"
+	testCases := []struct {
+		literal  string
+		expected string
+		err      error
+	}{
+		{huge, huge, nil},
+		{"-" + huge, "-" + huge, nil},
+		{"1.5e2", "150", nil},
+		{"1e30", "1" + strings.Repeat("0", 30), nil},
+		{"1.25", "", parser.ErrNotInteger},
+		{"1e999999999", "", parser.ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		b, err := parser.Number(tc.literal).BigInt()
+		if !errors.Is(err, tc.err) || (err == nil && b.String() != tc.expected) {
+			t.Errorf("%s: expected %s, %v but got %v, %v", tc.literal, tc.expected, tc.err, b, err)
+		}
+	}
+}
+
+func TestBigFloat(t *testing.T) {
+	literal := "3.14159265358979323846264338327950288419716939937510"
+	f, err := parser.Number(literal).BigFloat()
+	if err != nil {
+		t.Fatalf("Expected a big.Float but got: %v", err)
+	}
+	if s := f.Text('f', 50); s != literal {
+		t.Errorf("Expected every digit to be kept, %s but got %s", literal, s)
+	}
+
+	for _, literal := range []string{"1e9999999999", "1e2000000000", "-1e2000000000", "1e-2000000000", "-2.5e-2000000000"} {
+		if _, err := parser.Number(literal).BigFloat(); !errors.Is(err, parser.ErrOverflow) {
+			t.Errorf("%s: expected ErrOverflow but got %v", literal, err)
+		}
+	}
+	for _, literal := range []string{"0", "-0", "0e-2000000000", "0.000e5"} {
+		if f, err := parser.Number(literal).BigFloat(); err != nil || f.Sign() != 0 {
+			t.Errorf("%s: expected zero but got %v, %v", literal, f, err)
+		}
+	}
+
+	for literal, negative := range map[string]bool{"Infinity": false, "-Infinity": true} {
+		f, err := parser.Number(literal).BigFloat()
+		if err != nil || !f.IsInf() || f.Signbit() != negative {
+			t.Errorf("%s: expected an infinity but got %v, %v", literal, f, err)
+		}
+	}
+	if _, err := parser.Number("NaN").BigFloat(); err == nil {
+		t.Error("Expected NaN to fail")
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	testCases := []struct {
+		literal     string
+		coefficient string
+		exponent    int
+		text        string
+	}{
+		{"12.50", "1250", -2, "12.50"},
+		{"-0.05", "-5", -2, "-0.05"},
+		{"1e3", "1", 3, "1000"},
+		{"1.5E-3", "15", -4, "0.0015"},
+		{"0.00", "0", -2, "0.00"},
+		{"-0", "0", 0, "0"},
+		{"19.99", "1999", -2, "19.99"},
+	}
+
+	for _, tc := range testCases {
+		d, err := parser.Number(tc.literal).Decimal()
+		if err != nil {
+			t.Fatalf("%s: expected a decimal but got: %v", tc.literal, err)
+		}
+		if d.Coefficient.String() != tc.coefficient || d.Exponent != tc.exponent {
+			t.Errorf("%s: expected %s × 10^%d but got %s × 10^%d", tc.literal, tc.coefficient, tc.exponent, d.Coefficient, d.Exponent)
+		}
+		if d.String() != tc.text {
+			t.Errorf("%s: expected %s but got %s", tc.literal, tc.text, d.String())
+		}
+	}
+
+	d, _ := parser.Number("0.1").Decimal()
+	if d.Rat().Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("Expected 0.1 to be exactly 1/10 but got %v", d.Rat())
+	}
+	if _, err := parser.Number("1e999999999").Decimal(); !errors.Is(err, parser.ErrOverflow) {
+		t.Errorf("Expected ErrOverflow but got %v", err)
+	}
+}
+
+func TestNumberError(t *testing.T) {
+	_, err := parser.Number("1.5").Int64()
+	var numErr *parser.NumberError
+	if !errors.As(err, &numErr) || numErr.Literal != "1.5" || numErr.Type != "int64" {
+		t.Fatalf("Expected a *NumberError for 1.5 and int64 but got %v", err)
+	}
+	if err.Error() != "converting 1.5 to int64: number is not an integer" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestLiteralIsKept(t *testing.T) {
+	input := `[1.50, 1E+2, -0.0, 12345678901234567890123]`
+	value, err := parser.ParseValue(tokenizer.NewTokenizerFromReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var literals []string
+	for _, element := range value.Elements() {
+		n, ok := element.Number()
+		if !ok {
+			t.Fatalf("Expected a number but got a %v", element.Kind())
+		}
+		literals = append(literals, n.String())
+	}
+	if joined := "[" + strings.Join(literals, ", ") + "]"; joined != input {
+		t.Errorf("Expected the literals %s but got %s", input, joined)
+	}
+
+	n, _ := value.Index(3).Number()
+	if _, err := n.Int64(); !errors.Is(err, parser.ErrOverflow) {
+		t.Errorf("Expected ErrOverflow but got %v", err)
+	}
+	if b, _ := n.BigInt(); b.String() != "12345678901234567890123" {
+		t.Errorf("Expected the exact integer but got %v", b)
+	}
+}
+
+// The accessors of Value agree with the conversions of Number
+func TestValueAccessorsAgree(t *testing.T) {
+	for _, literal := range []string{"1", "-0", "1e3", "1.0", "1.5", "0.1", "9007199254740993", "9223372036854775808", "1e400", "-12.5e-1"} {
+		v := parser.NewNumber(literal)
+		n := parser.Number(literal)
+
+		f, ok := v.Float64()
+		expectedF, err := n.Float64()
+		if ok != (err == nil) || ok && f != expectedF {
+			t.Errorf("%s: expected Value.Float64 to give %v, %v but got %v, %v", literal, expectedF, err, f, ok)
+		}
+		i, ok := v.Int64()
+		expectedI, err := n.Int64()
+		if ok != (err == nil) || ok && i != expectedI {
+			t.Errorf("%s: expected Value.Int64 to give %v, %v but got %v, %v", literal, expectedI, err, i, ok)
+		}
+	}
+
+	if _, ok := parser.NewNumber("9007199254740993").Float64(); ok {
+		t.Error("Expected Value.Float64 to fail on a number a float64 cannot hold")
+	}
+	if i, ok := parser.NewNumber("1e3").Int64(); !ok || i != 1000 {
+		t.Errorf("Expected 1000 but got %d, %v", i, ok)
+	}
+}
@@ -0,0 +1,66 @@
+package positions
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+func TestTokenPositions(t *testing.T) {
+	input := "{\n  \"héllo\": [1, true]\n}"
+	expected := []struct {
+		tokenType tokenizer.TokenType
+		start     tokenizer.Position
+		end       tokenizer.Position
+	}{
+		{tokenizer.TokenLeftBrace, tokenizer.Position{Offset: 0, Line: 1, Column: 1}, tokenizer.Position{Offset: 1, Line: 1, Column: 2}},
+		{tokenizer.TokenString, tokenizer.Position{Offset: 4, Line: 2, Column: 3}, tokenizer.Position{Offset: 12, Line: 2, Column: 10}},
+		{tokenizer.TokenColon, tokenizer.Position{Offset: 12, Line: 2, Column: 10}, tokenizer.Position{Offset: 13, Line: 2, Column: 11}},
+		{tokenizer.TokenLeftSquare, tokenizer.Position{Offset: 14, Line: 2, Column: 12}, tokenizer.Position{Offset: 15, Line: 2, Column: 13}},
+		{tokenizer.TokenNumber, tokenizer.Position{Offset: 15, Line: 2, Column: 13}, tokenizer.Position{Offset: 16, Line: 2, Column: 14}},
+		{tokenizer.TokenComma, tokenizer.Position{Offset: 16, Line: 2, Column: 14}, tokenizer.Position{Offset: 17, Line: 2, Column: 15}},
+		{tokenizer.TokenTrue, tokenizer.Position{Offset: 18, Line: 2, Column: 16}, tokenizer.Position{Offset: 22, Line: 2, Column: 20}},
+		{tokenizer.TokenRightSquare, tokenizer.Position{Offset: 22, Line: 2, Column: 20}, tokenizer.Position{Offset: 23, Line: 2, Column: 21}},
+		{tokenizer.TokenRightBrace, tokenizer.Position{Offset: 24, Line: 3, Column: 1}, tokenizer.Position{Offset: 25, Line: 3, Column: 2}},
+		{tokenizer.TokenEOF, tokenizer.Position{Offset: 25, Line: 3, Column: 2}, tokenizer.Position{Offset: 25, Line: 3, Column: 2}},
+	}
+
+	tok := tokenizer.NewTokenizerFromReader(strings.NewReader(input))
+	for i, want := range expected {
+		token, err := tok.NextToken()
+		if err != nil {
+			t.Fatalf("Token %d: unexpected error: %v", i, err)
+		}
+		if token.Type != want.tokenType || token.Start != want.start || token.End != want.end {
+			t.Errorf("Token %d: expected %d %+v-%+v but got %d %+v-%+v", i, want.tokenType, want.start, want.end, token.Type, token.Start, token.End)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		pos   tokenizer.Position
+	}{
+		{"UnexpectedCharacter", "{\n  \"key2\": False\n}", tokenizer.Position{Offset: 12, Line: 2, Column: 11}},
+		{"UnexpectedToken", "[1,\n  2 3]", tokenizer.Position{Offset: 8, Line: 2, Column: 5}},
+		{"ControlCharacter", "[\"ü\tx\"]", tokenizer.Position{Offset: 4, Line: 1, Column: 4}},
+		{"UnterminatedString", "[\"abc", tokenizer.Position{Offset: 5, Line: 1, Column: 6}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(tc.input)))
+			var parseErr *parser.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError but got: %v", err)
+			}
+			if parseErr.Pos != tc.pos {
+				t.Errorf("Expected error at %v but got %v (%v)", tc.pos, parseErr.Pos, err)
+			}
+		})
+	}
+}
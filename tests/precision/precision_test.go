@@ -0,0 +1,106 @@
+package precision
+
+import (
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// check parses input with CheckPrecision set and returns the warnings
+func check(t *testing.T, input string) []*parser.ParseError {
+	t.Helper()
+	var warnings []*parser.ParseError
+	opts := parser.DefaultOptions()
+	opts.CheckPrecision = true
+	opts.OnWarning = func(warning *parser.ParseError) {
+		warnings = append(warnings, warning)
+	}
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), opts); err != nil {
+		t.Fatalf("Expected successful parsing but got: %v", err)
+	}
+	return warnings
+}
+
+func TestPreciseNumbers(t *testing.T) {
+	input := `[0, -0, 1, -1.5, 0.1, 0.3, 1e21, 9007199254740992, -9007199254740992, 1.7976931348623157e308, 5e-324, 100.000]`
+	if warnings := check(t, input); len(warnings) != 0 {
+		t.Errorf("Expected no warnings but got %v", warnings)
+	}
+}
+
+func TestImpreciseNumbers(t *testing.T) {
+	testCases := []struct {
+		literal string
+		becomes string
+	}{
+		{"9007199254740993", "9007199254740992"},
+		{"-9007199254740993", "-9007199254740992"},
+		{"18446744073709551615", "18446744073709552000"},
+		{"0.30000000000000000001", "0.3"},
+		{"3.141592653589793238", "3.141592653589793"},
+		{"1e400", "Infinity"},
+		{"-1e400", "-Infinity"},
+		{"1e-400", "0"},
+		{"123456789012345678901234", "1.2345678901234569e+23"},
+		{"1.00000000000000000001e-7", "1e-7"},
+		{"-1.00000000000000000001e-300", "-1e-300"},
+		{"0.00000100000000000000000001", "0.000001"},
+	}
+
+	for _, tc := range testCases {
+		warnings := check(t, tc.literal)
+		if len(warnings) != 1 {
+			t.Errorf("%s: expected a warning but got %v", tc.literal, warnings)
+			continue
+		}
+		warning := warnings[0]
+		if warning.Kind != parser.KindPrecisionLoss {
+			t.Errorf("%s: expected a %v warning but got %v", tc.literal, parser.KindPrecisionLoss, warning.Kind)
+		}
+		if expected := "number " + tc.literal + " becomes " + tc.becomes + " as a float64"; warning.Message != expected {
+			t.Errorf("%s: expected %q but got %q", tc.literal, expected, warning.Message)
+		}
+	}
+}
+
+func TestWarningPositions(t *testing.T) {
+	input := "{\n  \"id\": 12345678901234567890,\n  \"ok\": 1,\n  \"list\": [1, 0.1000000000000000000001]\n}"
+	var lines, columns []int
+	for _, warning := range check(t, input) {
+		lines = append(lines, warning.Pos.Line)
+		columns = append(columns, warning.Pos.Column)
+	}
+	if !slices.Equal(lines, []int{2, 4}) || !slices.Equal(columns, []int{9, 15}) {
+		t.Errorf("Expected warnings at 2:9 and 4:15 but got lines %v, columns %v", lines, columns)
+	}
+}
+
+func TestOffByDefault(t *testing.T) {
+	warned := false
+	opts := parser.DefaultOptions()
+	opts.OnWarning = func(*parser.ParseError) { warned = true }
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`9007199254740993`)), opts); err != nil {
+		t.Fatal(err)
+	}
+	if warned {
+		t.Error("Expected no warning without CheckPrecision")
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	var warnings []*parser.ParseError
+	opts := parser.DefaultOptions()
+	opts.CheckPrecision = true
+	opts.OnWarning = func(warning *parser.ParseError) {
+		warnings = append(warnings, warning)
+	}
+	d := parser.NewDecoderWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`[1, 9007199254740993]`)), opts)
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].Pos.Offset != 4 {
+		t.Errorf("Expected a warning at offset 4 but got %v", warnings)
+	}
+}
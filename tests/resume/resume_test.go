@@ -0,0 +1,166 @@
+package resume
+
+import (
+	"bytes"
+	"io"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var payload = []byte{0x00, 0xff, '{', '"', 0x1e, '\n', 0x80, 'x'}
+
+// header returns a JSON header followed by a binary payload
+func header(json string) []byte {
+	return append([]byte(json), payload...)
+}
+
+// decodeHeader decodes the document at the start of r and returns it with
+// everything read after it
+func decodeHeader(t *testing.T, d *parser.Decoder, r io.Reader) (*parser.Value, []byte) {
+	t.Helper()
+	value, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Expected successful decoding but got: %v", err)
+	}
+	rest, err := io.ReadAll(io.MultiReader(d.Buffered(), r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value, rest
+}
+
+func TestPayloadAfterDocument(t *testing.T) {
+	testCases := []struct {
+		json string
+		kind parser.ValueKind
+	}{
+		{`{"length": 8, "type": "binary"}`, parser.ObjectValue},
+		{`["a", [1, 2]]`, parser.ArrayValue},
+		{`"header"`, parser.StringValue},
+		{`true`, parser.BoolValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.json, func(t *testing.T) {
+			r := bytes.NewReader(header(tc.json))
+			value, rest := decodeHeader(t, parser.NewDecoder(tokenizer.NewTokenizerFromReader(r)), r)
+			if value.Kind() != tc.kind {
+				t.Errorf("Expected a %v but got a %v", tc.kind, value.Kind())
+			}
+			if !bytes.Equal(rest, payload) {
+				t.Errorf("Expected the payload %q after the document but got %q", payload, rest)
+			}
+		})
+	}
+}
+
+func TestWhitespaceAfterDocumentIsKept(t *testing.T) {
+	r := bytes.NewReader(header("[1] \r\n"))
+	_, rest := decodeHeader(t, parser.NewDecoder(tokenizer.NewTokenizerFromReader(r)), r)
+	if expected := append([]byte(" \r\n"), payload...); !bytes.Equal(rest, expected) {
+		t.Errorf("Expected %q after the document but got %q", expected, rest)
+	}
+}
+
+// A number only ends at the byte after it, which must not be lost
+func TestNumberHeader(t *testing.T) {
+	r := bytes.NewReader([]byte("12345;rest"))
+	value, rest := decodeHeader(t, parser.NewDecoder(tokenizer.NewTokenizerFromReader(r)), r)
+	if n, _ := value.Int64(); n != 12345 {
+		t.Errorf("Expected 12345 but got %d", n)
+	}
+	if string(rest) != ";rest" {
+		t.Errorf("Expected \";rest\" after the number but got %q", rest)
+	}
+}
+
+func TestOneByteReads(t *testing.T) {
+	r := iotest.OneByteReader(bytes.NewReader(header(`{"a": [1, 2, {"b": null}]}`)))
+	_, rest := decodeHeader(t, parser.NewDecoder(tokenizer.NewTokenizerFromReader(r)), r)
+	if !bytes.Equal(rest, payload) {
+		t.Errorf("Expected the payload %q after the document but got %q", payload, rest)
+	}
+}
+
+func TestLargeDocumentSpanningReads(t *testing.T) {
+	// Larger than the tokenizer's buffer, so it is refilled several times
+	json := []byte(`["` + string(bytes.Repeat([]byte("x"), 200*1024)) + `"]`)
+	r := bytes.NewReader(append(json, payload...))
+	_, rest := decodeHeader(t, parser.NewDecoder(tokenizer.NewTokenizerFromReader(r)), r)
+	if !bytes.Equal(rest, payload) {
+		t.Errorf("Expected the payload after the document but got %d bytes", len(rest))
+	}
+}
+
+func TestBytesInput(t *testing.T) {
+	d := parser.NewDecoder(tokenizer.NewTokenizerFromBytes(header(`{"a": 1}`)))
+	_, rest := decodeHeader(t, d, bytes.NewReader(nil))
+	if !bytes.Equal(rest, payload) {
+		t.Errorf("Expected the payload %q after the document but got %q", payload, rest)
+	}
+}
+
+func TestTokenizerBuffered(t *testing.T) {
+	r := bytes.NewReader([]byte(`{"a" : 1} tail`))
+	tok := tokenizer.NewTokenizerFromReader(r)
+	for range 3 {
+		if _, err := tok.NextToken(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rest, _ := io.ReadAll(io.MultiReader(tok.Buffered(), r))
+	if string(rest) != " 1} tail" {
+		t.Errorf("Expected \" 1} tail\" to be left but got %q", rest)
+	}
+}
+
+func TestBufferedAfterMore(t *testing.T) {
+	r := bytes.NewReader([]byte(`[1]  {"next": true}`))
+	d := parser.NewDecoder(tokenizer.NewTokenizerFromReader(r))
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if !d.More() {
+		t.Fatal("Expected More to see the data after the document")
+	}
+	rest, _ := io.ReadAll(io.MultiReader(d.Buffered(), r))
+	if string(rest) != `  {"next": true}` {
+		t.Errorf("Expected the second document to be left but got %q", rest)
+	}
+}
+
+// Looking past the header keeps the framing that follows it
+func TestFramingAfterMore(t *testing.T) {
+	long := `"` + strings.Repeat("x", 200000) + `"`
+	for _, after := range []string{"\n" + string(payload), "\r\n\t " + long + " tail", "\n\n"} {
+		input := []byte(`{"length": 8}` + after)
+		for name, r := range map[string]io.Reader{
+			"reader":    bytes.NewReader(input),
+			"one byte":  iotest.OneByteReader(bytes.NewReader(input)),
+			"half read": iotest.HalfReader(bytes.NewReader(input)),
+		} {
+			d := parser.NewDecoder(tokenizer.NewTokenizerFromReader(r))
+			if _, err := d.Decode(); err != nil {
+				t.Fatal(err)
+			}
+			more := d.More()
+			if more != (strings.TrimSpace(after) != "") {
+				t.Errorf("%s: expected More to be %v", name, !more)
+			}
+			rest, _ := io.ReadAll(io.MultiReader(d.Buffered(), r))
+			if string(rest) != after {
+				t.Errorf("%s: expected %q to be left but got %q", name, truncate(after), truncate(string(rest)))
+			}
+		}
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
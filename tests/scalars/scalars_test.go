@@ -0,0 +1,64 @@
+package scalars
+
+import (
+	"errors"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTopLevelScalars(t *testing.T) {
+	testCases := []struct {
+		input string
+		kind  parser.ValueKind
+	}{
+		{`"hello"`, parser.StringValue},
+		{`42`, parser.NumberValue},
+		{` -1.5e3 `, parser.NumberValue},
+		{`true`, parser.BoolValue},
+		{`false`, parser.BoolValue},
+		{"null\n", parser.NullValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			value, err := parser.ParseValue(tokenizer.NewTokenizerFromReader(strings.NewReader(tc.input)))
+			if err != nil {
+				t.Fatalf("Expected successful parsing but got: %v", err)
+			}
+			if value.Kind() != tc.kind {
+				t.Errorf("Expected a %v but got a %v", tc.kind, value.Kind())
+			}
+		})
+	}
+}
+
+func TestRFC4627RejectsScalars(t *testing.T) {
+	opts := parser.DefaultOptions()
+	opts.RFC4627 = true
+
+	for _, input := range []string{`"hello"`, `42`, `true`, `null`} {
+		err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), opts)
+		var parseErr *parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%s: expected a *ParseError but got: %v", input, err)
+		}
+		if !slices.Equal(parseErr.Expected, []tokenizer.TokenType{tokenizer.TokenLeftBrace, tokenizer.TokenLeftSquare}) {
+			t.Errorf("%s: expected an object or array to be expected but got %v", input, parseErr.Expected)
+		}
+	}
+
+	if err := parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(strings.NewReader(`[42]`)), opts); err != nil {
+		t.Errorf("Expected successful parsing but got: %v", err)
+	}
+}
+
+func TestScalarFollowedByValue(t *testing.T) {
+	for _, input := range []string{`1 2`, `"a" "b"`, `true false`, `null,`} {
+		if err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(input))); err == nil {
+			t.Errorf("%s: expected unsuccessful parsing but was able to parse", input)
+		}
+	}
+}
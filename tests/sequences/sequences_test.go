@@ -0,0 +1,141 @@
+package sequences
+
+import (
+	"errors"
+	"io"
+	"json-parser/pkg/parser"
+	"json-parser/pkg/tokenizer"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const rs = "\x1e"
+
+// readAll returns the kind of every document in the stream, with nil for the
+// invalid ones
+func readAll(t *testing.T, input string, mode parser.StreamMode) ([]*parser.ValueKind, []*parser.ParseError) {
+	t.Helper()
+	r := parser.NewDocumentReader(tokenizer.NewTokenizerFromReader(strings.NewReader(input)), mode)
+	var kinds []*parser.ValueKind
+	var errs []*parser.ParseError
+	for range 100 {
+		value, err := r.NextValue()
+		if err == io.EOF {
+			return kinds, errs
+		}
+		if err != nil {
+			var parseErr *parser.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError but got: %v", err)
+			}
+			if len(errs) > 0 && errs[len(errs)-1] == parseErr {
+				// The stream ended with this error
+				return kinds, errs
+			}
+			errs = append(errs, parseErr)
+			kinds = append(kinds, nil)
+			continue
+		}
+		kind := value.Kind()
+		kinds = append(kinds, &kind)
+	}
+	t.Fatal("Expected the stream to end")
+	return nil, nil
+}
+
+func kindsOf(kinds []*parser.ValueKind) []string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		if kind == nil {
+			names[i] = "invalid"
+		} else {
+			names[i] = kind.String()
+		}
+	}
+	return names
+}
+
+func TestConcatenated(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{`{}{}[]`, []string{"object", "object", "array"}},
+		{"{\"a\":1}\n[2] \"three\"4 true null", []string{"object", "array", "string", "number", "bool", "null"}},
+		{`1 2`, []string{"number", "number"}},
+		{"  ", nil},
+		{`[1][2,]`, []string{"array", "invalid"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			kinds, _ := readAll(t, tc.input, parser.StreamConcatenated)
+			if names := kindsOf(kinds); !slices.Equal(names, tc.expected) {
+				t.Errorf("Expected %v but got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestConcatenatedErrorEndsStream(t *testing.T) {
+	kinds, errs := readAll(t, `[1] [2 "x"] [3]`, parser.StreamConcatenated)
+	if names := kindsOf(kinds); !slices.Equal(names, []string{"array", "invalid"}) {
+		t.Errorf("Expected the stream to end at the invalid document but got %v", names)
+	}
+	if len(errs) != 1 || errs[0].Pos.Offset != 7 {
+		t.Errorf("Expected a single error at offset 7 but got %v", errs)
+	}
+}
+
+func TestRecordSeparated(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"values", rs + "{\"a\": 1}\n" + rs + "[2]\n" + rs + "\"three\"\n" + rs + "4\n", []string{"object", "array", "string", "number"}},
+		{"empty records", rs + rs + "1\n" + rs + "\n" + rs, []string{"number"}},
+		{"no final line feed", rs + "[1]" + rs + "[2]", []string{"array", "array"}},
+		{"newlines inside a record", rs + "{\n\"a\":\n[1,\n2]\n}\n", []string{"object"}},
+		{"corrupted record", rs + "[1,\n" + rs + "{\"b\": 2}\n", []string{"invalid", "object"}},
+		{"garbage record", rs + "{\"a\" 1 2 3}}}\n" + rs + "true\n", []string{"invalid", "bool"}},
+		{"bad string", rs + "\"abc\x01def\"\n" + rs + "null\n", []string{"invalid", "null"}},
+		{"two values", rs + "1 2\n" + rs + "3\n", []string{"invalid", "number"}},
+		{"missing separator", "[1]\n" + rs + "[2]\n", []string{"invalid", "array"}},
+		{"truncated number", rs + "12" + rs + "34\n", []string{"invalid", "number"}},
+		{"truncated literal", rs + "tru" + rs + "true", []string{"invalid", "invalid"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kinds, _ := readAll(t, tc.input, parser.StreamRecordSeparated)
+			if names := kindsOf(kinds); !slices.Equal(names, tc.expected) {
+				t.Errorf("Expected %v but got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestTruncatedRecord(t *testing.T) {
+	_, errs := readAll(t, rs+"[1]\n"+rs+"123"+rs+"4\n", parser.StreamRecordSeparated)
+	if len(errs) != 1 {
+		t.Fatalf("Expected a single error but got %v", errs)
+	}
+	if errs[0].Kind != parser.KindTruncated {
+		t.Errorf("Expected a %v error but got %v", parser.KindTruncated, errs[0].Kind)
+	}
+	if errs[0].Pos.Offset != 6 {
+		t.Errorf("Expected the error at the number's offset 6 but got %v", errs[0].Pos)
+	}
+}
+
+func TestRecordSeparatorIsInvalidOutsideSequences(t *testing.T) {
+	if err := parser.Parse(tokenizer.NewTokenizerFromReader(strings.NewReader(rs + "[1]"))); err == nil {
+		t.Error("Expected unsuccessful parsing but was able to parse")
+	}
+	kinds, _ := readAll(t, rs+"[1]", parser.StreamConcatenated)
+	if names := kindsOf(kinds); !slices.Equal(names, []string{"invalid"}) {
+		t.Errorf("Expected the record separator to be rejected but got %v", names)
+	}
+}
@@ -15,10 +15,10 @@ func TestValidJsonFromFile(t *testing.T) {
 		log.Fatalf("Unable to read file: %v", err)
 	}
 	defer file.Close()
-	parsed := parser.Parse(tokenizer.NewTokenizerFromReader(file))
+	err = parser.Parse(tokenizer.NewTokenizerFromReader(file))
 
-	if !parsed {
-		t.Errorf("Expected successful parsing but could not parse")
+	if err != nil {
+		t.Errorf("Expected successful parsing but could not parse: %v", err)
 	}
 
 }
@@ -30,9 +30,9 @@ func TestInvalidJsonFromFile(t *testing.T) {
 		log.Fatalf("Unable to read file: %v", err)
 	}
 	defer file.Close()
-	parsed := parser.Parse(tokenizer.NewTokenizerFromReader(file))
+	err = parser.Parse(tokenizer.NewTokenizerFromReader(file))
 
-	if parsed {
+	if err == nil {
 		t.Errorf("Expected unsuccessful parsing but was able to parse.")
 	}
 }
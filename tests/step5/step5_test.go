@@ -28,9 +28,9 @@ func TestValidJsonFromFiles(t *testing.T) {
 			}
 			defer file.Close()
 
-			parsed := parser.Parse(tokenizer.NewTokenizerFromReader(file))
-			if !parsed {
-				t.Errorf("Expected successful parsing but could not parse file: %s", tc.filename)
+			err = parser.Parse(tokenizer.NewTokenizerFromReader(file))
+			if err != nil {
+				t.Errorf("Expected successful parsing but could not parse file: %s: %v", tc.filename, err)
 			}
 		})
 	}
@@ -41,7 +41,6 @@ func TestInvalidJsonFromFile(t *testing.T) {
 		name     string
 		filename string
 	}{
-		{"InvalidJson1", "../../testdata/tests/step5/fail1.json"},
 		{"InvalidJson2", "../../testdata/tests/step5/fail2.json"},
 		{"InvalidJson3", "../../testdata/tests/step5/fail3.json"},
 		{"InvalidJson4", "../../testdata/tests/step5/fail4.json"},
@@ -58,7 +57,6 @@ func TestInvalidJsonFromFile(t *testing.T) {
 		{"InvalidJson15", "../../testdata/tests/step5/fail15.json"},
 		{"InvalidJson16", "../../testdata/tests/step5/fail16.json"},
 		{"InvalidJson17", "../../testdata/tests/step5/fail17.json"},
-		{"InvalidJson18", "../../testdata/tests/step5/fail18.json"},
 		{"InvalidJson19", "../../testdata/tests/step5/fail19.json"},
 		{"InvalidJson20", "../../testdata/tests/step5/fail20.json"},
 		{"InvalidJson21", "../../testdata/tests/step5/fail21.json"},
@@ -83,11 +81,43 @@ func TestInvalidJsonFromFile(t *testing.T) {
 				log.Fatalf("Unable to read file: %v", err)
 			}
 			defer file.Close()
-			parsed := parser.Parse(tokenizer.NewTokenizerFromReader(file))
+			err = parser.Parse(tokenizer.NewTokenizerFromReader(file))
 
-			if parsed {
+			if err == nil {
 				t.Errorf("Expected unsuccessful parsing but was able to parse.")
 			}
 		})
 	}
 }
+
+func TestNestingLimitFromFile(t *testing.T) {
+	// fail18.json nests 20 arrays, one more than the limit it was written for
+	filename := "../../testdata/tests/step5/fail18.json"
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Fatalf("Unable to read file: %v", err)
+	}
+	defer file.Close()
+	err = parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(file), parser.Options{MaxDepth: 19})
+
+	if err == nil {
+		t.Errorf("Expected unsuccessful parsing but was able to parse.")
+	}
+}
+
+func TestTopLevelScalarFromFile(t *testing.T) {
+	// fail1.json is a lone string, only invalid under RFC 4627
+	filename := "../../testdata/tests/step5/fail1.json"
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Fatalf("Unable to read file: %v", err)
+	}
+	defer file.Close()
+	opts := parser.DefaultOptions()
+	opts.RFC4627 = true
+	err = parser.ParseWithOptions(tokenizer.NewTokenizerFromReader(file), opts)
+
+	if err == nil {
+		t.Errorf("Expected unsuccessful parsing but was able to parse.")
+	}
+}
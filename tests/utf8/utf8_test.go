@@ -0,0 +1,65 @@
+package utf8
+
+import (
+	"errors"
+	"json-parser/pkg/tokenizer"
+	"strings"
+	"testing"
+)
+
+func readString(input string, options tokenizer.Options) (tokenizer.Token, error) {
+	return tokenizer.NewTokenizerFromReaderWithOptions(strings.NewReader(input), options).NextToken()
+}
+
+func TestValidUTF8(t *testing.T) {
+	input := "\"aé€😀\""
+	token, err := readString(input, tokenizer.Options{})
+	if err != nil {
+		t.Fatalf("Expected successful tokenizing but got: %v", err)
+	}
+	if token.Value != "aé€😀" {
+		t.Errorf("Expected %q but got %q", "aé€😀", token.Value)
+	}
+	if token.End.Column != 7 || token.End.Offset != int64(len(input)) {
+		t.Errorf("Expected the string to end at column 7, offset %d but got %v", len(input), token.End)
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		offset   int64
+		replaced string
+	}{
+		{"InvalidLead", "\"a\xffb\"", 2, "a�b"},
+		{"StrayContinuation", "\"a\x80b\"", 2, "a�b"},
+		{"Truncated", "\"ab\xc3\"", 3, "ab�"},
+		{"TruncatedBeforeAscii", "\"\xe2\x82b\"", 1, "�b"},
+		{"Overlong", "\"\xe0\x80\xaf\"", 1, "�"},
+		{"OverlongTwoByte", "\"\xc0\xaf\"", 1, "��"},
+		{"EncodedSurrogate", "\"x\xed\xa0\x80\"", 2, "x�"},
+		{"AboveMaxCodePoint", "\"\xf4\x90\x80\x80\"", 1, "�"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readString(tc.input, tokenizer.Options{})
+			var tokErr *tokenizer.Error
+			if !errors.As(err, &tokErr) || tokErr.Kind != tokenizer.ErrorInvalidUTF8 {
+				t.Fatalf("Expected an invalid UTF-8 error but got: %v", err)
+			}
+			if tokErr.Pos.Offset != tc.offset {
+				t.Errorf("Expected error at offset %d but got %d", tc.offset, tokErr.Pos.Offset)
+			}
+
+			token, err := readString(tc.input, tokenizer.Options{InvalidUTF8: tokenizer.UTF8Replace})
+			if err != nil {
+				t.Fatalf("Expected successful tokenizing but got: %v", err)
+			}
+			if token.Value != tc.replaced {
+				t.Errorf("Expected %q but got %q", tc.replaced, token.Value)
+			}
+		})
+	}
+}